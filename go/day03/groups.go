@@ -0,0 +1,451 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+)
+
+type GroupingStrategy int
+
+const (
+	// GroupingConsecutive splits the rucksacks into consecutive groups.
+	GroupingConsecutive GroupingStrategy = iota
+	// GroupingSlidingWindow checks every window of consecutive rucksacks and
+	// keeps the ones sharing exactly one badge.
+	GroupingSlidingWindow
+	// GroupingAuto searches for a partition of all the rucksacks into groups
+	// sharing exactly one badge each, regardless of their order.
+	GroupingAuto
+)
+
+func ParseGroupingStrategy(s string) (GroupingStrategy, error) {
+	strategyMap := map[string]GroupingStrategy{
+		"consecutive": GroupingConsecutive,
+		"sliding":     GroupingSlidingWindow,
+		"auto":        GroupingAuto,
+	}
+
+	strategy, ok := strategyMap[s]
+	if !ok {
+		return 0, fmt.Errorf("unknown grouping strategy: %s", s)
+	}
+
+	return strategy, nil
+}
+
+var (
+	ErrNoBadge        = errors.New("group does not share exactly one item")
+	ErrSearchTooLarge = errors.New("grouping search is too large")
+)
+
+const (
+	// maxAutoCandidates is the most candidate groups the auto grouping keeps,
+	// and maxAutoSteps the most steps it takes enumerating and combining them
+	// before giving up.
+	maxAutoCandidates = 1 << 20
+	maxAutoSteps      = 1 << 27
+)
+
+type Group struct {
+	// Indexes are the positions of the rucksacks in the input.
+	Indexes   []int
+	Rucksacks []*Rucksack
+	Badge     rune
+}
+
+func (g *Group) String() string {
+	idxs := make([]string, 0, len(g.Indexes))
+	for _, idx := range g.Indexes {
+		idxs = append(idxs, fmt.Sprintf("%d", idx+1))
+	}
+
+	return fmt.Sprintf("rucksacks [%s] share badge %q", strings.Join(idxs, ","), g.Badge)
+}
+
+func newGroup(rucksacks []*Rucksack, idxs []int) (*Group, error) {
+	g := &Group{
+		Indexes:   make([]int, 0, len(idxs)),
+		Rucksacks: make([]*Rucksack, 0, len(idxs)),
+	}
+
+	for _, idx := range idxs {
+		g.Indexes = append(g.Indexes, idx)
+		g.Rucksacks = append(g.Rucksacks, rucksacks[idx])
+	}
+
+	common := findCommonItems(g.Rucksacks)
+	if len(common) != 1 {
+		return nil, fmt.Errorf("%w: rucksacks %v have %d common items", ErrNoBadge, idxs, len(common))
+	}
+
+	g.Badge = common[0]
+	return g, nil
+}
+
+// findCommonItems returns the items present in every rucksack of the group,
+// sorted so the result does not depend on map iteration order.
+func findCommonItems(group []*Rucksack) []rune {
+	if len(group) == 0 {
+		return nil
+	}
+
+	common := make([]rune, 0)
+	for item := range group[0].AllItems {
+		inAll := true
+		for _, r := range group[1:] {
+			if _, ok := r.AllItems[item]; !ok {
+				inAll = false
+				break
+			}
+		}
+
+		if inAll {
+			common = append(common, item)
+		}
+	}
+
+	sort.Slice(common, func(i, j int) bool {
+		return common[i] < common[j]
+	})
+
+	return common
+}
+
+func FormGroups(rucksacks []*Rucksack, size int, strategy GroupingStrategy) ([]*Group, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("invalid group size: %d", size)
+	}
+
+	switch strategy {
+	case GroupingConsecutive:
+		return formConsecutiveGroups(rucksacks, size)
+
+	case GroupingSlidingWindow:
+		return formSlidingWindowGroups(rucksacks, size), nil
+
+	case GroupingAuto:
+		return formAutoGroups(rucksacks, size)
+
+	default:
+		return nil, fmt.Errorf("unknown grouping strategy: %d", strategy)
+	}
+}
+
+func formConsecutiveGroups(rucksacks []*Rucksack, size int) ([]*Group, error) {
+	if len(rucksacks)%size != 0 {
+		return nil, fmt.Errorf("the number of rucksacks (%d) is not divisible by %d", len(rucksacks), size)
+	}
+
+	groups := make([]*Group, 0, len(rucksacks)/size)
+
+	for i := 0; i < len(rucksacks); i += size {
+		idxs := make([]int, 0, size)
+		for j := i; j < i+size; j++ {
+			idxs = append(idxs, j)
+		}
+
+		g, err := newGroup(rucksacks, idxs)
+		if err != nil {
+			return nil, err
+		}
+
+		groups = append(groups, g)
+	}
+
+	return groups, nil
+}
+
+func formSlidingWindowGroups(rucksacks []*Rucksack, size int) []*Group {
+	groups := make([]*Group, 0)
+
+	for i := 0; i+size <= len(rucksacks); i++ {
+		idxs := make([]int, 0, size)
+		for j := i; j < i+size; j++ {
+			idxs = append(idxs, j)
+		}
+
+		// windows without a single badge are not groups, just skip them
+		g, err := newGroup(rucksacks, idxs)
+		if err != nil {
+			continue
+		}
+
+		groups = append(groups, g)
+	}
+
+	return groups
+}
+
+// itemSet is a bitset of items, indexed by the position assigned to each item
+// type when building the sets of a search.
+type itemSet []uint64
+
+// intersectInto stores the intersection of a and b in s and returns how many
+// items it has.
+func (s itemSet) intersectInto(a, b itemSet) (n int) {
+	for i := range s {
+		s[i] = a[i] & b[i]
+		n += bits.OnesCount64(s[i])
+	}
+
+	return n
+}
+
+func (s itemSet) count() (n int) {
+	for _, w := range s {
+		n += bits.OnesCount64(w)
+	}
+
+	return n
+}
+
+// buildItemSets returns the set of items of every rucksack, along with the
+// rucksacks holding each item.
+func buildItemSets(rucksacks []*Rucksack) ([]itemSet, [][]int) {
+	itemIdx := make(map[rune]int, 0)
+	for _, r := range rucksacks {
+		for item := range r.AllItems {
+			if _, ok := itemIdx[item]; !ok {
+				itemIdx[item] = len(itemIdx)
+			}
+		}
+	}
+
+	words := (len(itemIdx) + 63) / 64
+	sets := make([]itemSet, 0, len(rucksacks))
+	byItem := make([][]int, len(itemIdx))
+
+	for i, r := range rucksacks {
+		set := make(itemSet, words)
+		for item := range r.AllItems {
+			idx := itemIdx[item]
+			set[idx/64] |= 1 << (idx % 64)
+			byItem[idx] = append(byItem[idx], i)
+		}
+
+		sets = append(sets, set)
+	}
+
+	// map iteration adds them in any order
+	for _, idxs := range byItem {
+		sort.Ints(idxs)
+	}
+
+	return sets, byItem
+}
+
+// formAutoGroups searches for a partition of the rucksacks into groups of the
+// given size sharing exactly one badge. It first enumerates every candidate
+// group and then solves the exact cover, always branching on the rucksack
+// with the fewest candidates left and backtracking as soon as a rucksack runs
+// out of them. It gives up when either part takes too long.
+func formAutoGroups(rucksacks []*Rucksack, size int) ([]*Group, error) {
+	if len(rucksacks)%size != 0 {
+		return nil, fmt.Errorf("the number of rucksacks (%d) is not divisible by %d", len(rucksacks), size)
+	}
+
+	if len(rucksacks) == 0 {
+		return make([]*Group, 0), nil
+	}
+
+	sets, byItem := buildItemSets(rucksacks)
+	steps := 0
+
+	// candidates[c] holds the indexes of the rucksacks of candidate group c and
+	// byRucksack[i] the candidate groups rucksack i belongs to.
+	candidates := make([][]int, 0)
+	byRucksack := make([][]int, len(rucksacks))
+
+	// the candidates are searched badge by badge, only among the rucksacks
+	// holding it. A group shares a single item, so it is found only once, and
+	// common[d] keeps the items shared by its first d+1 rucksacks.
+	idxs := make([]int, size)
+	common := make([]itemSet, size)
+	for d := range common {
+		common[d] = make(itemSet, len(sets[0]))
+	}
+
+	var enumerate func(holders []int, depth, next int) error
+	enumerate = func(holders []int, depth, next int) error {
+		// stop when there are not enough holders left to fill the group
+		for j := next; j <= len(holders)-(size-depth); j++ {
+			steps++
+			if steps > maxAutoSteps {
+				return fmt.Errorf("%w: more than %d steps enumerating groups of %d", ErrSearchTooLarge, maxAutoSteps, size)
+			}
+
+			i := holders[j]
+			prev := sets[i]
+			if depth > 0 {
+				prev = common[depth-1]
+			}
+
+			if common[depth].intersectInto(prev, sets[i]) == 0 {
+				continue
+			}
+
+			idxs[depth] = i
+			if depth < size-1 {
+				if err := enumerate(holders, depth+1, j+1); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if common[depth].count() != 1 {
+				continue
+			}
+
+			if len(candidates) == maxAutoCandidates {
+				return fmt.Errorf("%w: more than %d candidate groups of %d", ErrSearchTooLarge, maxAutoCandidates, size)
+			}
+
+			c := make([]int, size)
+			copy(c, idxs)
+			candidates = append(candidates, c)
+		}
+
+		return nil
+	}
+
+	for _, holders := range byItem {
+		if err := enumerate(holders, 0, 0); err != nil {
+			return nil, err
+		}
+	}
+
+	// sort them by their rucksacks, so the search tries them in the same order
+	// whatever the order of the badges
+	sort.Slice(candidates, func(i, j int) bool {
+		for k := range candidates[i] {
+			if candidates[i][k] != candidates[j][k] {
+				return candidates[i][k] < candidates[j][k]
+			}
+		}
+
+		return false
+	})
+
+	for c, members := range candidates {
+		for _, idx := range members {
+			byRucksack[idx] = append(byRucksack[idx], c)
+		}
+	}
+
+	used := make([]bool, len(rucksacks))
+	dead := make([]bool, len(candidates))
+	live := make([]int, len(rucksacks))
+	for i, cs := range byRucksack {
+		live[i] = len(cs)
+	}
+
+	// take marks the members of candidate c as used and kills every other
+	// candidate sharing a member with it, returning what it killed so it can
+	// be undone.
+	take := func(c int) []int {
+		killed := make([]int, 0)
+		for _, idx := range candidates[c] {
+			used[idx] = true
+			for _, other := range byRucksack[idx] {
+				if dead[other] {
+					continue
+				}
+
+				dead[other] = true
+				killed = append(killed, other)
+				for _, m := range candidates[other] {
+					live[m]--
+				}
+			}
+		}
+
+		return killed
+	}
+
+	undo := func(c int, killed []int) {
+		for _, other := range killed {
+			dead[other] = false
+			for _, m := range candidates[other] {
+				live[m]++
+			}
+		}
+
+		for _, idx := range candidates[c] {
+			used[idx] = false
+		}
+	}
+
+	chosen := make([]int, 0, len(rucksacks)/size)
+
+	var search func() bool
+	search = func() bool {
+		steps++
+		if steps > maxAutoSteps {
+			return false
+		}
+
+		best := -1
+		for i, u := range used {
+			if u {
+				continue
+			}
+
+			if live[i] == 0 {
+				return false
+			}
+
+			if best == -1 || live[i] < live[best] {
+				best = i
+			}
+		}
+
+		// every rucksack has a group
+		if best == -1 {
+			return true
+		}
+
+		for _, c := range byRucksack[best] {
+			if dead[c] {
+				continue
+			}
+
+			killed := take(c)
+			chosen = append(chosen, c)
+			if search() {
+				return true
+			}
+			chosen = chosen[:len(chosen)-1]
+			undo(c, killed)
+		}
+
+		return false
+	}
+
+	if !search() {
+		if steps > maxAutoSteps {
+			return nil, fmt.Errorf("%w: more than %d steps combining groups of %d", ErrSearchTooLarge, maxAutoSteps, size)
+		}
+
+		return nil, fmt.Errorf("%w: no partition into groups of %d found", ErrNoBadge, size)
+	}
+
+	groups := make([]*Group, 0, len(chosen))
+	for _, c := range chosen {
+		g, err := newGroup(rucksacks, candidates[c])
+		if err != nil {
+			return nil, err
+		}
+
+		groups = append(groups, g)
+	}
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Indexes[0] < groups[j].Indexes[0]
+	})
+
+	return groups, nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import "testing"
+
+func TestFormGroupsWithoutRucksacks(t *testing.T) {
+	strategies := []GroupingStrategy{GroupingConsecutive, GroupingSlidingWindow, GroupingAuto}
+
+	for _, strategy := range strategies {
+		for _, size := range []int{1, 3} {
+			groups, err := FormGroups(nil, size, strategy)
+			if err != nil {
+				t.Fatalf("strategy %d, size %d: %v", strategy, size, err)
+			}
+
+			if len(groups) != 0 {
+				t.Fatalf("strategy %d, size %d: got %d groups, want none", strategy, size, len(groups))
+			}
+		}
+	}
+}
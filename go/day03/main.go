@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
 
 	"github.com/rarguelloF/advent-of-code-2022/input"
 )
@@ -27,37 +28,15 @@ func (r *Rucksack) GetRepeatedItem() (rune, error) {
 	return 0, errors.New("not found")
 }
 
-func itemTypeToPriority(itemType rune) int {
-	asciiPos := int(itemType)
-	if asciiPos >= 97 {
-		// a to z
-		// a is 97 in ascii -> 1 priority
-		return asciiPos - 96
-	}
-
-	// A to Z
-	// A is position 65 in ascii -> 27 priority
-	return asciiPos - 38
-}
-
-func findCommonItem(group [3]*Rucksack) (rune, error) {
-	g1, g2, g3 := group[0], group[1], group[2]
-	for item := range g1.AllItems {
-		if _, ok := g2.AllItems[item]; ok {
-			if _, ok := g3.AllItems[item]; ok {
-				return item, nil
-			}
-		}
-	}
-
-	return 0, errors.New("not found")
-}
-
-func readInput() ([]*Rucksack, error) {
+func readInput(scheme PriorityScheme) ([]*Rucksack, error) {
 	rucksacks := make([]*Rucksack, 0)
+	lineNum := 0
 
 	processLine := func(line string) error {
-		numElems := len(line)
+		lineNum++
+
+		items := []rune(line)
+		numElems := len(items)
 		if numElems%2 != 0 {
 			return fmt.Errorf("odd number of elements in rucksack (%d)", numElems)
 		}
@@ -70,7 +49,11 @@ func readInput() ([]*Rucksack, error) {
 			},
 		}
 
-		for idx, item := range line {
+		for idx, item := range items {
+			if _, ok := scheme.Priority(item); !ok {
+				return &InvalidItemError{Line: lineNum, Column: idx + 1, Item: item}
+			}
+
 			if idx >= numElems/2 {
 				r.Compartments[1][item]++
 			} else {
@@ -91,7 +74,7 @@ func readInput() ([]*Rucksack, error) {
 	return rucksacks, nil
 }
 
-func PartOne(rucksacks []*Rucksack) {
+func PartOne(rucksacks []*Rucksack, scheme PriorityScheme) {
 	sum := 0
 	for _, r := range rucksacks {
 		item, err := r.GetRepeatedItem()
@@ -99,48 +82,90 @@ func PartOne(rucksacks []*Rucksack) {
 			log.Fatal(err)
 		}
 
-		sum += itemTypeToPriority(item)
+		priority, ok := scheme.Priority(item)
+		if !ok {
+			log.Fatalf("item %q does not belong to the priority scheme", item)
+		}
+
+		sum += priority
 	}
 
 	fmt.Printf("Part 1: %d\n", sum)
 }
 
-func PartTwo(rucksacks []*Rucksack) {
-	if len(rucksacks)%3 != 0 {
-		log.Fatal("the number of rucksacks is not divisible by 3")
+func PartTwo(rucksacks []*Rucksack, scheme PriorityScheme, size int, strategy GroupingStrategy) {
+	groups, err := FormGroups(rucksacks, size, strategy)
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	groups := make([][3]*Rucksack, 0, len(rucksacks)/3)
+	sum := 0
+	for _, g := range groups {
+		if input.HasFlag("verbose") {
+			fmt.Println(g)
+		}
 
-	for i := 0; i < len(rucksacks); i += 3 {
-		g := [3]*Rucksack{
-			rucksacks[i],
-			rucksacks[i+1],
-			rucksacks[i+2],
+		priority, ok := scheme.Priority(g.Badge)
+		if !ok {
+			log.Fatalf("badge %q does not belong to the priority scheme", g.Badge)
 		}
 
-		groups = append(groups, g)
+		sum += priority
 	}
 
-	sum := 0
-	for _, g := range groups {
-		item, err := findCommonItem(g)
+	fmt.Printf("Part 2: %d\n", sum)
+}
+
+func readGroupingOptions() (int, GroupingStrategy, error) {
+	size := 3
+	strategy := GroupingConsecutive
+
+	if sizeStr, ok := input.FlagValue("group-size"); ok {
+		n, err := strconv.Atoi(sizeStr)
 		if err != nil {
-			log.Fatal(err)
+			return 0, 0, fmt.Errorf("group size is not a number: %s", sizeStr)
 		}
 
-		sum += itemTypeToPriority(item)
+		size = n
 	}
 
-	fmt.Printf("Part 2: %d\n", sum)
+	if strategyStr, ok := input.FlagValue("grouping"); ok {
+		s, err := ParseGroupingStrategy(strategyStr)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		strategy = s
+	}
+
+	return size, strategy, nil
+}
+
+func readPriorityScheme() (PriorityScheme, error) {
+	name, ok := input.FlagValue("priorities")
+	if !ok {
+		name = "letters"
+	}
+
+	return ParsePriorityScheme(name)
 }
 
 func main() {
-	rucksacks, err := readInput()
+	scheme, err := readPriorityScheme()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	rucksacks, err := readInput(scheme)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	size, strategy, err := readGroupingOptions()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	PartOne(rucksacks)
-	PartTwo(rucksacks)
+	PartOne(rucksacks, scheme)
+	PartTwo(rucksacks, scheme, size, strategy)
 }
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type PriorityScheme interface {
+	// Priority returns the priority of the given item type, and false if the
+	// item does not belong to the scheme.
+	Priority(item rune) (int, bool)
+}
+
+// AlphabetScheme gives each item of the alphabet its position as priority,
+// starting at 1.
+type AlphabetScheme struct {
+	priorities map[rune]int
+}
+
+func NewAlphabetScheme(alphabet string) (*AlphabetScheme, error) {
+	s := &AlphabetScheme{
+		priorities: make(map[rune]int, 0),
+	}
+
+	for idx, item := range []rune(alphabet) {
+		if _, ok := s.priorities[item]; ok {
+			return nil, fmt.Errorf("item %q is repeated in alphabet", item)
+		}
+
+		s.priorities[item] = idx + 1
+	}
+
+	if len(s.priorities) == 0 {
+		return nil, errors.New("empty alphabet")
+	}
+
+	return s, nil
+}
+
+func (s *AlphabetScheme) Priority(item rune) (int, bool) {
+	p, ok := s.priorities[item]
+	return p, ok
+}
+
+// TableScheme uses an explicit item to priority table.
+type TableScheme map[rune]int
+
+// ParseTableScheme reads a table like "a=1,b=5,C=10". Items are single
+// characters, so a comma cannot be one of them.
+func ParseTableScheme(s string) (TableScheme, error) {
+	table := make(TableScheme, 0)
+
+	for _, entry := range strings.Split(s, ",") {
+		// split at the last = so that = itself can be an item
+		sep := strings.LastIndex(entry, "=")
+		if sep == -1 {
+			return nil, fmt.Errorf("expected item=priority in table: %s", entry)
+		}
+
+		items := []rune(entry[:sep])
+		if len(items) != 1 {
+			return nil, fmt.Errorf("expected a single item in table: %s", entry)
+		}
+
+		p, err := strconv.Atoi(entry[sep+1:])
+		if err != nil {
+			return nil, fmt.Errorf("priority is not a number in table: %s", entry)
+		}
+
+		if _, ok := table[items[0]]; ok {
+			return nil, fmt.Errorf("item %q is repeated in table", items[0])
+		}
+
+		table[items[0]] = p
+	}
+
+	return table, nil
+}
+
+func (s TableScheme) Priority(item rune) (int, bool) {
+	p, ok := s[item]
+	return p, ok
+}
+
+const (
+	// a to z have priorities 1 to 26 and A to Z 27 to 52
+	alphabetLetters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	// every printable ascii character, from ! (priority 1) to ~ (priority 94)
+	alphabetASCII = "!\"#$%&'()*+,-./0123456789:;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+)
+
+// ParsePriorityScheme returns one of the builtin schemes by name, a custom
+// alphabet when the name has the "alphabet:" prefix or a custom table when it
+// has the "table:" one.
+func ParsePriorityScheme(s string) (PriorityScheme, error) {
+	const (
+		customPrefix = "alphabet:"
+		tablePrefix  = "table:"
+	)
+
+	switch {
+	case s == "letters":
+		return NewAlphabetScheme(alphabetLetters)
+
+	case s == "ascii":
+		return NewAlphabetScheme(alphabetASCII)
+
+	case strings.HasPrefix(s, customPrefix):
+		return NewAlphabetScheme(strings.TrimPrefix(s, customPrefix))
+
+	case strings.HasPrefix(s, tablePrefix):
+		return ParseTableScheme(strings.TrimPrefix(s, tablePrefix))
+
+	default:
+		return nil, fmt.Errorf("unknown priority scheme: %s", s)
+	}
+}
+
+type InvalidItemError struct {
+	Line   int
+	Column int
+	Item   rune
+}
+
+func (e *InvalidItemError) Error() string {
+	return fmt.Sprintf("line %d, column %d: item %q does not belong to the priority scheme", e.Line, e.Column, e.Item)
+}
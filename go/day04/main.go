@@ -3,32 +3,30 @@ package main
 import (
 	"fmt"
 	"log"
-	"strconv"
 	"strings"
 
 	"github.com/rarguelloF/advent-of-code-2022/input"
+	"github.com/rarguelloF/advent-of-code-2022/interval"
 )
 
 const inputName = "day04"
 
 type Range [2]int
 
+func (r Range) Interval() interval.Interval {
+	return interval.Closed(r[0], r[1])
+}
+
 func (r Range) Size() int {
-	return r[1] - r[0] + 1
+	return r.Interval().Len()
 }
 
 func (r Range) ContainsAll(other Range) bool {
-	rStart, rEnd := r[0], r[1]
-	oStart, oEnd := other[0], other[1]
-
-	return oStart >= rStart && oStart <= rEnd && oEnd >= rStart && oEnd <= rEnd
+	return r.Interval().ContainsInterval(other.Interval())
 }
 
 func (r Range) ContainsAny(other Range) bool {
-	rStart, rEnd := r[0], r[1]
-	oStart, oEnd := other[0], other[1]
-
-	return (oStart < rStart && oEnd >= rStart) || (oStart >= rStart && oStart <= rEnd)
+	return r.Interval().Overlaps(other.Interval())
 }
 
 type ElfPair [2]Range
@@ -76,31 +74,124 @@ func PartTwo(pairs []ElfPair) {
 	fmt.Printf("Part 2: %d\n", sum)
 }
 
-func readInput() ([]ElfPair, error) {
+// ElfID identifies an elf by the index of its pair in the input and its
+// position inside the pair.
+type ElfID struct {
+	Pair int
+	Elf  int
+}
+
+func (e ElfID) String() string {
+	return fmt.Sprintf("pair %d elf %d", e.Pair+1, e.Elf+1)
+}
+
+func assignmentEntries(pairs []ElfPair) []interval.Entry[ElfID] {
+	entries := make([]interval.Entry[ElfID], 0, len(pairs)*2)
+	for pairIdx, p := range pairs {
+		for elfIdx, r := range p {
+			entries = append(entries, interval.Entry[ElfID]{
+				Interval: r.Interval(),
+				Value:    ElfID{Pair: pairIdx, Elf: elfIdx},
+			})
+		}
+	}
+
+	return entries
+}
+
+func NewAssignmentTree(pairs []ElfPair) *interval.Tree[ElfID] {
+	return interval.NewTree(assignmentEntries(pairs))
+}
+
+func FindOverlapping(pairs []ElfPair, query Range) {
+	tree := NewAssignmentTree(pairs)
+	overlapping := tree.Overlapping(query.Interval())
+
+	fmt.Printf("Assignments overlapping %d-%d: %d\n", query[0], query[1], len(overlapping))
+	for _, e := range overlapping {
+		fmt.Printf("  %s: %s\n", e.Value, formatSections(e.Interval))
+	}
+}
+
+// AnalyseAssignments looks for overlaps between every elf of every pair, not
+// only the ones inside the same pair. The sections from the min section up to
+// the last assignment, or up to the max section if -max-section is given,
+// that nobody covers are reported as gaps.
+func AnalyseAssignments(pairs []ElfPair, opts *ParseOptions, verbose bool) {
+	entries := assignmentEntries(pairs)
+
+	maxEnd := opts.MinSection
+	if _, ok := input.FlagValue("max-section"); ok {
+		maxEnd = opts.MaxSection + 1
+	} else {
+		for _, e := range entries {
+			if e.Interval.End > maxEnd {
+				maxEnd = e.Interval.End
+			}
+		}
+	}
+
+	r := interval.SweepWithin(entries, interval.HalfOpen(opts.MinSection, maxEnd))
+
+	fmt.Printf("Sections covered: %d\n", r.Covered)
+	fmt.Printf("Max elves on one section: %d (sections %s)\n", r.MaxDepth, formatSections(r.MaxDepthAt))
+
+	uncovered := 0
+	gaps := make([]string, 0, len(r.Gaps))
+	for _, g := range r.Gaps {
+		uncovered += g.Len()
+		gaps = append(gaps, formatSections(g))
+	}
+	fmt.Printf("Sections nobody covers: %d [%s]\n", uncovered, strings.Join(gaps, " "))
+
+	fmt.Printf("Overlapping pairs of elves: %d\n", len(r.Overlaps))
+	if verbose {
+		for _, o := range r.Overlaps {
+			fmt.Printf("  %s and %s: sections %s\n", o.A.Value, o.B.Value, formatSections(o.Intersection))
+		}
+	}
+}
+
+// formatSections prints the interval with the closed notation of the input.
+func formatSections(i interval.Interval) string {
+	if i.Len() == 1 {
+		return fmt.Sprintf("%d", i.Start)
+	}
+
+	return fmt.Sprintf("%d-%d", i.Start, i.Last())
+}
+
+func readInput(opts *ParseOptions) ([]ElfPair, error) {
 	pairs := make([]ElfPair, 0)
+	lineNum := 0
 
 	processLine := func(line string) error {
+		lineNum++
+
 		rangesStr := strings.Split(line, ",")
 		if len(rangesStr) != 2 {
-			return fmt.Errorf("unknown line format: %s", line)
+			return fmt.Errorf("line %d: unknown line format: %s", lineNum, line)
 		}
 
 		pair := ElfPair{}
 
 		for elfIdx, rangeStr := range rangesStr {
-			numsStr := strings.Split(rangeStr, "-")
-			if len(numsStr) != 2 {
-				return fmt.Errorf("unknown line format: %s", line)
-			}
-
-			for i, ns := range numsStr {
-				n, err := strconv.Atoi(ns)
-				if err != nil {
-					return fmt.Errorf("line contains non-numeric values: %s", line)
+			r, err := ParseRange(rangeStr)
+			if err != nil {
+				problem := RangeProblemMalformed
+				if strings.TrimSpace(rangeStr) == "" {
+					problem = RangeProblemEmpty
 				}
 
-				pair[elfIdx][i] = n
+				return &RangeError{Line: lineNum, Elf: elfIdx, Range: rangeStr, Problem: problem}
+			}
+
+			r, err = opts.Validate(r, lineNum, elfIdx)
+			if err != nil {
+				return err
 			}
+
+			pair[elfIdx] = r
 		}
 
 		pairs = append(pairs, pair)
@@ -115,11 +206,33 @@ func readInput() ([]ElfPair, error) {
 }
 
 func main() {
-	pairs, err := readInput()
+	opts, err := readParseOptions()
 	if err != nil {
 		log.Fatal(err)
 	}
 
+	pairs, err := readInput(opts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, w := range opts.Warnings {
+		log.Printf("warning: %v\n", w)
+	}
+
 	PartOne(pairs)
 	PartTwo(pairs)
+
+	if queryStr, ok := input.FlagValue("overlapping"); ok {
+		query, err := ParseRange(queryStr)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		FindOverlapping(pairs, query)
+	}
+
+	if input.HasFlag("analyse") {
+		AnalyseAssignments(pairs, opts, input.HasFlag("verbose"))
+	}
 }
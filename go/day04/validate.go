@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rarguelloF/advent-of-code-2022/input"
+)
+
+type ParseMode int
+
+const (
+	// ParseModeStrict rejects any invalid range.
+	ParseModeStrict ParseMode = iota
+	// ParseModeLenient swaps inverted ranges and clamps the out-of-bounds ones
+	// to the allowed sections, keeping a warning for each of them.
+	ParseModeLenient
+)
+
+type RangeProblem int
+
+const (
+	RangeProblemMalformed RangeProblem = iota
+	RangeProblemEmpty
+	RangeProblemInverted
+	RangeProblemOutOfBounds
+)
+
+func (p RangeProblem) String() string {
+	switch p {
+	case RangeProblemMalformed:
+		return "malformed range"
+
+	case RangeProblemEmpty:
+		return "empty range"
+
+	case RangeProblemInverted:
+		return "inverted range"
+
+	case RangeProblemOutOfBounds:
+		return "range out of bounds"
+
+	default:
+		return fmt.Sprintf("unknown problem (%d)", int(p))
+	}
+}
+
+type RangeError struct {
+	Line    int
+	Elf     int
+	Range   string
+	Problem RangeProblem
+}
+
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("line %d, elf %d: %s: %q", e.Line, e.Elf+1, e.Problem, e.Range)
+}
+
+type ParseOptions struct {
+	Mode ParseMode
+	// MinSection and MaxSection are the lowest and highest valid section IDs.
+	// MaxSection can be at most maxSectionLimit.
+	MinSection int
+	MaxSection int
+	// Warnings holds the ranges fixed in lenient mode.
+	Warnings []*RangeError
+}
+
+// maxSectionLimit is the highest section a range can end at, since ranges
+// are turned into half-open intervals ending one section later.
+const maxSectionLimit = math.MaxInt - 1
+
+func NewParseOptions() *ParseOptions {
+	return &ParseOptions{
+		Mode:       ParseModeStrict,
+		MinSection: 1,
+		MaxSection: maxSectionLimit,
+		Warnings:   make([]*RangeError, 0),
+	}
+}
+
+// Validate checks the range against the options, returning it normalised
+// when running in lenient mode.
+func (o *ParseOptions) Validate(r Range, line, elf int) (Range, error) {
+	newErr := func(problem RangeProblem) *RangeError {
+		return &RangeError{
+			Line:    line,
+			Elf:     elf,
+			Range:   fmt.Sprintf("%d-%d", r[0], r[1]),
+			Problem: problem,
+		}
+	}
+
+	fixed := r
+
+	if fixed[0] > fixed[1] {
+		if o.Mode == ParseModeStrict {
+			return r, newErr(RangeProblemInverted)
+		}
+
+		o.Warnings = append(o.Warnings, newErr(RangeProblemInverted))
+		fixed[0], fixed[1] = fixed[1], fixed[0]
+	}
+
+	if fixed[0] > o.MaxSection || fixed[1] < o.MinSection {
+		// clamping would leave no sections at all, so not even lenient mode
+		// can fix it
+		return r, newErr(RangeProblemOutOfBounds)
+	}
+
+	if fixed[0] < o.MinSection || fixed[1] > o.MaxSection {
+		if o.Mode == ParseModeStrict {
+			return r, newErr(RangeProblemOutOfBounds)
+		}
+
+		o.Warnings = append(o.Warnings, newErr(RangeProblemOutOfBounds))
+
+		if fixed[0] < o.MinSection {
+			fixed[0] = o.MinSection
+		}
+
+		if fixed[1] > o.MaxSection {
+			fixed[1] = o.MaxSection
+		}
+	}
+
+	return fixed, nil
+}
+
+var rangeRe = regexp.MustCompile(`^(-?\d+)-(-?\d+)$`)
+
+// ParseRange reads a range in the "start-end" format of the input. Both
+// numbers may be negative, the bounds are checked later by ParseOptions.
+func ParseRange(s string) (Range, error) {
+	r := Range{}
+
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return r, fmt.Errorf("%s: %q", RangeProblemEmpty, s)
+	}
+
+	match := rangeRe.FindStringSubmatch(s)
+	if len(match) != 3 {
+		return r, fmt.Errorf("unknown range format: %s", s)
+	}
+
+	for i, ns := range match[1:] {
+		n, err := strconv.Atoi(ns)
+		if err != nil {
+			return r, fmt.Errorf("range contains non-numeric values: %s", s)
+		}
+
+		r[i] = n
+	}
+
+	return r, nil
+}
+
+func readParseOptions() (*ParseOptions, error) {
+	opts := NewParseOptions()
+
+	if input.HasFlag("lenient") {
+		opts.Mode = ParseModeLenient
+	}
+
+	for name, dest := range map[string]*int{
+		"min-section": &opts.MinSection,
+		"max-section": &opts.MaxSection,
+	} {
+		if valStr, ok := input.FlagValue(name); ok {
+			n, err := strconv.Atoi(valStr)
+			if err != nil {
+				return nil, fmt.Errorf("%s is not a number: %s", name, valStr)
+			}
+
+			*dest = n
+		}
+	}
+
+	if opts.MaxSection > maxSectionLimit {
+		return nil, fmt.Errorf("max section (%d) is bigger than %d", opts.MaxSection, maxSectionLimit)
+	}
+
+	if opts.MinSection > opts.MaxSection {
+		return nil, fmt.Errorf("min section (%d) is bigger than max section (%d)", opts.MinSection, opts.MaxSection)
+	}
+
+	return opts, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type CraneStats struct {
+	Lifts  int
+	Crates int
+	Cost   int
+}
+
+func (s *CraneStats) Add(other CraneStats) {
+	s.Lifts += other.Lifts
+	s.Crates += other.Crates
+	s.Cost += other.Cost
+}
+
+type Crane interface {
+	Name() string
+	// Move carries out an instruction already checked against the stacks.
+	Move(stacks CrateStacks, ins *Instruction) CraneStats
+}
+
+// CrateMover9000 lifts a single crate at a time, so the moved crates end up in
+// reverse order. Every lift costs 1.
+type CrateMover9000 struct{}
+
+func (*CrateMover9000) Name() string {
+	return "9000"
+}
+
+func (*CrateMover9000) Move(stacks CrateStacks, ins *Instruction) CraneStats {
+	from, to := stacks[ins.From], stacks[ins.To]
+
+	for i := 0; i < ins.NumItems; i++ {
+		item, _ := from.Pop()
+		to.Push(item)
+	}
+
+	return CraneStats{Lifts: ins.NumItems, Crates: ins.NumItems, Cost: ins.NumItems}
+}
+
+// CrateMover9001 lifts all the crates at once, keeping their order. Every
+// lift costs 1.
+type CrateMover9001 struct{}
+
+func (*CrateMover9001) Name() string {
+	return "9001"
+}
+
+func (*CrateMover9001) Move(stacks CrateStacks, ins *Instruction) CraneStats {
+	items, _ := stacks[ins.From].PopN(ins.NumItems)
+	stacks[ins.To].PushN(items)
+
+	lifts := 0
+	if ins.NumItems > 0 {
+		lifts = 1
+	}
+
+	return CraneStats{Lifts: lifts, Crates: ins.NumItems, Cost: lifts}
+}
+
+// LimitedCrane lifts at most Capacity crates at a time, keeping the order of
+// the crates in each lift. Every lift costs 1.
+type LimitedCrane struct {
+	Capacity int
+}
+
+func (c *LimitedCrane) Name() string {
+	return fmt.Sprintf("limited:%d", c.Capacity)
+}
+
+func (c *LimitedCrane) Move(stacks CrateStacks, ins *Instruction) CraneStats {
+	from, to := stacks[ins.From], stacks[ins.To]
+
+	lifts := 0
+	for left := ins.NumItems; left > 0; left -= c.Capacity {
+		n := c.Capacity
+		if left < n {
+			n = left
+		}
+
+		items, _ := from.PopN(n)
+		to.PushN(items)
+		lifts++
+	}
+
+	return CraneStats{Lifts: lifts, Crates: ins.NumItems, Cost: lifts}
+}
+
+// WeightedCrane moves crates like its base crane, but every lift costs
+// LiftCost and every crate costs its weight.
+type WeightedCrane struct {
+	Base     Crane
+	LiftCost int
+	Weight   func(crate string) int
+}
+
+func (c *WeightedCrane) Name() string {
+	return "weighted:" + c.Base.Name()
+}
+
+func (c *WeightedCrane) Move(stacks CrateStacks, ins *Instruction) CraneStats {
+	from := *stacks[ins.From]
+
+	weight := 0
+	for _, crate := range from[len(from)-ins.NumItems:] {
+		weight += c.Weight(crate)
+	}
+
+	stats := c.Base.Move(stacks, ins)
+	stats.Cost = stats.Lifts*c.LiftCost + weight
+
+	return stats
+}
+
+// letterWeight gives crates labelled A to Z a weight from 1 to 26, and any
+// other crate a weight of 1.
+func letterWeight(crate string) int {
+	if len(crate) == 1 && crate[0] >= 'A' && crate[0] <= 'Z' {
+		return int(crate[0]-'A') + 1
+	}
+
+	return 1
+}
+
+// ParseCrane returns a crane by name: "9000", "9001", "limited:<capacity>"
+// or "weighted:<crane>".
+func ParseCrane(name string) (Crane, error) {
+	switch {
+	case name == "9000":
+		return &CrateMover9000{}, nil
+
+	case name == "9001":
+		return &CrateMover9001{}, nil
+
+	case strings.HasPrefix(name, "limited:"):
+		capacity, err := strconv.Atoi(strings.TrimPrefix(name, "limited:"))
+		if err != nil || capacity < 1 {
+			return nil, fmt.Errorf("invalid crane capacity: %s", name)
+		}
+
+		return &LimitedCrane{Capacity: capacity}, nil
+
+	case strings.HasPrefix(name, "weighted:"):
+		base, err := ParseCrane(strings.TrimPrefix(name, "weighted:"))
+		if err != nil {
+			return nil, err
+		}
+
+		return &WeightedCrane{Base: base, LiftCost: 1, Weight: letterWeight}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown crane: %s", name)
+	}
+}
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type DrawingError struct {
+	// Line and Column are where the problem was found, starting at 1.
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *DrawingError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *DrawingError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	ErrMisalignedCrate = errors.New("crate is not aligned with exactly one stack")
+	ErrUnexpectedChar  = errors.New("unexpected character")
+	ErrUnclosedCrate   = errors.New("crate is not closed")
+	ErrEmptyLabel      = errors.New("crate has an empty label")
+)
+
+// span holds the first and last byte positions of a token in a line.
+type span struct {
+	start int
+	end   int
+}
+
+func (s span) overlaps(other span) bool {
+	return s.start <= other.end && other.start <= s.end
+}
+
+// ParseDrawing reads the stacks from the puzzle drawing, where the last line
+// holds the stack numbers. The column of every stack is taken from the
+// position of its number, so crates can have labels of any width and lines
+// can miss their trailing spaces, as long as every crate is above exactly one
+// stack number.
+func ParseDrawing(lines []string) (CrateStacks, error) {
+	if len(lines) == 0 {
+		return nil, errors.New("empty drawing")
+	}
+
+	stacks := make(CrateStacks, 0)
+
+	baseIdx := len(lines) - 1
+	base, crateLines := lines[baseIdx], lines[:baseIdx]
+
+	ids := make([]int, 0)
+	columns := make([]span, 0)
+
+	for i := 0; i < len(base); {
+		if base[i] == ' ' {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(base) && base[i] != ' ' {
+			i++
+		}
+
+		idStr := base[start:i]
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			return nil, &DrawingError{
+				Line:   baseIdx + 1,
+				Column: start + 1,
+				Err:    fmt.Errorf("stack number is not a number: %s", idStr),
+			}
+		}
+
+		if _, ok := stacks[id]; ok {
+			return nil, &DrawingError{
+				Line:   baseIdx + 1,
+				Column: start + 1,
+				Err:    fmt.Errorf("repeated stack number: %d", id),
+			}
+		}
+
+		stacks[id] = &Stack{}
+		ids = append(ids, id)
+		columns = append(columns, span{start: start, end: i - 1})
+	}
+
+	if len(ids) == 0 {
+		return nil, &DrawingError{Line: baseIdx + 1, Column: 1, Err: errors.New("no stack numbers found")}
+	}
+
+	// lines go from top to bottom, so each crate goes below the ones already
+	// found for its stack
+	for lineIdx, line := range crateLines {
+		for i := 0; i < len(line); i++ {
+			switch line[i] {
+			case ' ':
+				continue
+
+			case '[':
+				// handled below
+
+			default:
+				return nil, &DrawingError{Line: lineIdx + 1, Column: i + 1, Err: fmt.Errorf("%w: %q", ErrUnexpectedChar, line[i])}
+			}
+
+			closeIdx := strings.IndexByte(line[i:], ']')
+			if closeIdx == -1 {
+				return nil, &DrawingError{Line: lineIdx + 1, Column: i + 1, Err: ErrUnclosedCrate}
+			}
+
+			crate := span{start: i, end: i + closeIdx}
+			label := line[crate.start+1 : crate.end]
+			if strings.TrimSpace(label) == "" {
+				return nil, &DrawingError{Line: lineIdx + 1, Column: i + 1, Err: ErrEmptyLabel}
+			}
+
+			stackIdx := -1
+			for colIdx, col := range columns {
+				if !crate.overlaps(col) {
+					continue
+				}
+
+				if stackIdx != -1 {
+					stackIdx = -1
+					break
+				}
+
+				stackIdx = colIdx
+			}
+
+			if stackIdx == -1 {
+				return nil, &DrawingError{Line: lineIdx + 1, Column: i + 1, Err: fmt.Errorf("%w: [%s]", ErrMisalignedCrate, label)}
+			}
+
+			stacks[ids[stackIdx]].PushLeft(label)
+			i = crate.end
+		}
+	}
+
+	return stacks, nil
+}
+
+// Render draws the stacks in the same format as the puzzle input, so that
+// ParseDrawing(Render()) gives back the same stacks.
+func (c CrateStacks) Render() string {
+	return strings.Join(c.renderLines(), "\n")
+}
+
+func (c CrateStacks) renderLines() []string {
+	ids := c.IDs()
+
+	// every column is as wide as its widest label or stack number, plus the
+	// brackets
+	width, height := 1, 0
+	for _, id := range ids {
+		s := *c[id]
+		if len(s) > height {
+			height = len(s)
+		}
+
+		if w := len(strconv.Itoa(id)); w > width {
+			width = w
+		}
+
+		for _, crate := range s {
+			if len(crate) > width {
+				width = len(crate)
+			}
+		}
+	}
+
+	lines := make([]string, 0, height+1)
+	cells := make([]string, len(ids))
+
+	for level := height - 1; level >= 0; level-- {
+		for i, id := range ids {
+			s := *c[id]
+			if level < len(s) {
+				cells[i] = center("["+s[level]+"]", width+2)
+			} else {
+				cells[i] = strings.Repeat(" ", width+2)
+			}
+		}
+
+		lines = append(lines, strings.Join(cells, " "))
+	}
+
+	for i, id := range ids {
+		cells[i] = center(strconv.Itoa(id), width+2)
+	}
+	lines = append(lines, strings.Join(cells, " "))
+
+	return lines
+}
+
+func center(s string, width int) string {
+	left := (width - len(s)) / 2
+	right := width - len(s) - left
+	return strings.Repeat(" ", left) + s + strings.Repeat(" ", right)
+}
+
+// Diff renders both stacks aligned by their base line, marking with - the
+// lines only in expected and with + the ones only in actual. It returns an
+// empty string if both drawings are the same.
+func Diff(expected, actual CrateStacks) string {
+	exp, act := expected.renderLines(), actual.renderLines()
+	if strings.Join(exp, "\n") == strings.Join(act, "\n") {
+		return ""
+	}
+
+	// pad the shortest drawing at the top so the base lines match up
+	for len(exp) < len(act) {
+		exp = append([]string{""}, exp...)
+	}
+	for len(act) < len(exp) {
+		act = append([]string{""}, act...)
+	}
+
+	lines := make([]string, 0, len(exp))
+	for i := range exp {
+		if exp[i] == act[i] {
+			lines = append(lines, "  "+exp[i])
+			continue
+		}
+
+		if exp[i] != "" {
+			lines = append(lines, "- "+exp[i])
+		}
+		if act[i] != "" {
+			lines = append(lines, "+ "+act[i])
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
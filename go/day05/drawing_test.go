@@ -0,0 +1,99 @@
+package main
+
+import (
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const labelChars = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
+
+// randomStacks returns up to 8 stacks with IDs picked out of 1 to 30, so
+// there are gaps between them and some IDs have 2 digits, and labels of up to
+// maxLabel characters.
+func randomStacks(r *rand.Rand, maxLabel int) CrateStacks {
+	stacks := make(CrateStacks, 0)
+
+	for n := 1 + r.Intn(8); len(stacks) < n; {
+		s := make(Stack, r.Intn(6))
+		for i := range s {
+			label := make([]byte, 1+r.Intn(maxLabel))
+			for j := range label {
+				label[j] = labelChars[r.Intn(len(labelChars))]
+			}
+
+			s[i] = string(label)
+		}
+
+		stacks[1+r.Intn(30)] = &s
+	}
+
+	return stacks
+}
+
+// sameStacks tells whether both have the same IDs and the same crates on
+// each of them.
+func sameStacks(a, b CrateStacks) bool {
+	if !reflect.DeepEqual(a.IDs(), b.IDs()) {
+		return false
+	}
+
+	for id, s := range a {
+		if len(*s) != len(*b[id]) {
+			return false
+		}
+
+		for i := range *s {
+			if (*s)[i] != (*b[id])[i] {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func TestParseDrawing(t *testing.T) {
+	lines := []string{
+		"    [D]",
+		"[N] [C]",
+		"[Z] [M] [P]",
+		" 1   2   3",
+	}
+
+	stacks, err := ParseDrawing(lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := CrateStacks{1: &Stack{"Z", "N"}, 2: &Stack{"M", "C", "D"}, 3: &Stack{"P"}}
+	if !sameStacks(stacks, want) {
+		t.Fatalf("got %s, want %s", stacks.Render(), want.Render())
+	}
+}
+
+func TestRenderRoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(2022))
+
+	for i := 0; i < 2000; i++ {
+		stacks := randomStacks(r, 1+r.Intn(5))
+		lines := strings.Split(stacks.Render(), "\n")
+
+		// the input can come with or without the trailing spaces
+		if r.Intn(2) == 0 {
+			for j := range lines {
+				lines[j] = strings.TrimRight(lines[j], " ")
+			}
+		}
+
+		parsed, err := ParseDrawing(lines)
+		if err != nil {
+			t.Fatalf("%v in:\n%s", err, strings.Join(lines, "\n"))
+		}
+
+		if !sameStacks(parsed, stacks) {
+			t.Fatalf("got:\n%s\nwant:\n%s", parsed.Render(), stacks.Render())
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidCount    = errors.New("invalid number of crates")
+	ErrUnknownStack    = errors.New("unknown stack")
+	ErrNotEnoughCrates = errors.New("not enough crates")
+)
+
+type InstructionError struct {
+	// Index is the position of the failing instruction, starting at 0.
+	Index       int
+	Instruction *Instruction
+	Err         error
+}
+
+func (e *InstructionError) Error() string {
+	return fmt.Sprintf("instruction %d (%s): %v", e.Index+1, e.Instruction, e.Err)
+}
+
+func (e *InstructionError) Unwrap() error {
+	return e.Err
+}
+
+// Check validates the instruction against the current state of the stacks.
+func (c CrateStacks) Check(ins *Instruction) error {
+	if ins.NumItems < 0 {
+		return fmt.Errorf("%w: %d", ErrInvalidCount, ins.NumItems)
+	}
+
+	from, ok := c[ins.From]
+	if !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownStack, ins.From)
+	}
+
+	if _, ok := c[ins.To]; !ok {
+		return fmt.Errorf("%w: %d", ErrUnknownStack, ins.To)
+	}
+
+	if ins.NumItems > len(*from) {
+		return fmt.Errorf("%w: stack %d has %d, wants %d", ErrNotEnoughCrates, ins.From, len(*from), ins.NumItems)
+	}
+
+	return nil
+}
+
+// Apply checks the instruction and, if valid, moves the crates with the
+// given crane.
+func (c CrateStacks) Apply(ins *Instruction, crane Crane) (CraneStats, error) {
+	if err := c.Check(ins); err != nil {
+		return CraneStats{}, err
+	}
+
+	return crane.Move(c, ins), nil
+}
+
+// Run applies the instructions in order and stops at the first invalid one.
+// When atomic is set, the stacks are rolled back to their state before the
+// first instruction if any of them fails, and no stats are returned.
+func (c CrateStacks) Run(instructions []*Instruction, crane Crane, atomic bool) (CraneStats, error) {
+	var snapshot CrateStacks
+	if atomic {
+		snapshot = c.GetCopy()
+	}
+
+	stats := CraneStats{}
+
+	for idx, ins := range instructions {
+		insStats, err := c.Apply(ins, crane)
+		if err != nil {
+			if atomic {
+				c.Restore(snapshot)
+				stats = CraneStats{}
+			}
+
+			return stats, &InstructionError{Index: idx, Instruction: ins, Err: err}
+		}
+
+		stats.Add(insStats)
+	}
+
+	return stats, nil
+}
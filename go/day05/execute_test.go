@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// failingBatch moves some crates and then asks for more than there are left.
+func failingBatch() []*Instruction {
+	return []*Instruction{
+		{NumItems: 1, From: 2, To: 1},
+		{NumItems: 2, From: 1, To: 3},
+		{NumItems: 5, From: 2, To: 3},
+	}
+}
+
+func sampleStacks() CrateStacks {
+	return CrateStacks{1: &Stack{"Z", "N"}, 2: &Stack{"M", "C", "D"}, 3: &Stack{"P"}}
+}
+
+func TestAtomicRunRollsBack(t *testing.T) {
+	for _, crane := range []Crane{&CrateMover9000{}, &CrateMover9001{}, &LimitedCrane{Capacity: 1}} {
+		stacks := sampleStacks()
+		stack1 := stacks[1]
+
+		stats, err := stacks.Run(failingBatch(), crane, true)
+
+		var insErr *InstructionError
+		if !errors.As(err, &insErr) || insErr.Index != 2 || !errors.Is(err, ErrNotEnoughCrates) {
+			t.Fatalf("crane %s: got error %v, want not enough crates at instruction 3", crane.Name(), err)
+		}
+
+		if stats != (CraneStats{}) {
+			t.Fatalf("crane %s: got stats %+v after rolling back", crane.Name(), stats)
+		}
+
+		if !sameStacks(stacks, sampleStacks()) {
+			t.Fatalf("crane %s: stacks changed to:\n%s", crane.Name(), stacks.Render())
+		}
+
+		if stacks[1] != stack1 {
+			t.Fatalf("crane %s: stack 1 was replaced instead of restored", crane.Name())
+		}
+	}
+}
+
+func TestRunStopsHalfway(t *testing.T) {
+	stacks := sampleStacks()
+
+	if _, err := stacks.Run(failingBatch(), &CrateMover9000{}, false); err == nil {
+		t.Fatal("expected an error")
+	}
+
+	want := CrateStacks{1: &Stack{"Z"}, 2: &Stack{"M", "C"}, 3: &Stack{"P", "D", "N"}}
+	if !sameStacks(stacks, want) {
+		t.Fatalf("got:\n%s\nwant:\n%s", stacks.Render(), want.Render())
+	}
+}
+
+func TestRunCraneKeepsRolledBackStacks(t *testing.T) {
+	stacks := sampleStacks()
+
+	result, _ := runCrane(stacks, failingBatch(), &CrateMover9001{}, true)
+	if !sameStacks(result, sampleStacks()) {
+		t.Fatalf("got:\n%s", result.Render())
+	}
+
+	if got := result.TopCrates(); got != "NDP" {
+		t.Fatalf("top crates = %s, want NDP", got)
+	}
+}
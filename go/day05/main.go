@@ -4,7 +4,9 @@ import (
 	"fmt"
 	"log"
 	"regexp"
+	"sort"
 	"strconv"
+	"strings"
 
 	"github.com/rarguelloF/advent-of-code-2022/input"
 )
@@ -28,16 +30,15 @@ func (s *Stack) Pop() (string, bool) {
 	return val, true
 }
 
+// PopN removes the top n values, keeping their order. If the stack does not
+// have enough values it is left untouched.
 func (s *Stack) PopN(n int) ([]string, bool) {
-	if s.IsEmpty() {
-		return []string{""}, false
-	}
-
-	if n > len(*s) {
-		n = len(*s)
+	if n < 0 || n > len(*s) {
+		return nil, false
 	}
 
-	vals := (*s)[len(*s)-n : len(*s)]
+	vals := make([]string, n)
+	copy(vals, (*s)[len(*s)-n:])
 	*s = (*s)[:len(*s)-n]
 	return vals, true
 }
@@ -76,87 +77,160 @@ func (c CrateStacks) GetCopy() CrateStacks {
 	return cp
 }
 
+// Restore sets the contents of every stack back to the ones in the snapshot,
+// keeping the same stack pointers.
+func (c CrateStacks) Restore(snapshot CrateStacks) {
+	for k, v := range snapshot {
+		cpVal := make(Stack, len(*v))
+		copy(cpVal, *v)
+
+		if s, ok := c[k]; ok {
+			*s = cpVal
+		} else {
+			c[k] = &cpVal
+		}
+	}
+
+	for k := range c {
+		if _, ok := snapshot[k]; !ok {
+			delete(c, k)
+		}
+	}
+}
+
+// IDs returns the stack IDs in increasing order.
+func (c CrateStacks) IDs() []int {
+	ids := make([]int, 0, len(c))
+	for k := range c {
+		ids = append(ids, k)
+	}
+
+	sort.Ints(ids)
+	return ids
+}
+
+func (c CrateStacks) TopCrates() string {
+	topCrates := ""
+
+	for _, id := range c.IDs() {
+		if val := c[id].Peek(); val != "" {
+			topCrates += val
+		}
+	}
+
+	return topCrates
+}
+
 type Instruction struct {
 	NumItems int
 	From     int
 	To       int
 }
 
-func PartOne(stacks CrateStacks, instructions []*Instruction) {
-	// create a copy to not modify the original
-	stacks = stacks.GetCopy()
+func (i *Instruction) String() string {
+	return fmt.Sprintf("move %d from %d to %d", i.NumItems, i.From, i.To)
+}
 
-	topCrates := ""
+// runCrane moves the crates of a copy of the stacks, so the original ones are
+// not modified. When atomic is set, a failing instruction is reported and the
+// stacks are returned as they were before the first one.
+func runCrane(stacks CrateStacks, instructions []*Instruction, crane Crane, atomic bool) (CrateStacks, CraneStats) {
+	stacks = stacks.GetCopy()
 
-	for _, ins := range instructions {
-		for i := 0; i < ins.NumItems; i++ {
-			item, ok := stacks[ins.From].Pop()
-			if ok {
-				stacks[ins.To].Push(item)
-			}
+	stats, err := stacks.Run(instructions, crane, atomic)
+	if err != nil {
+		// otherwise the stacks are left halfway through the instructions
+		if !atomic {
+			log.Fatal(err)
 		}
+
+		fmt.Printf("Crane %s rolled back: %v\n", crane.Name(), err)
 	}
 
-	numStacks := len(stacks)
-	for i := 1; i <= numStacks; i++ {
-		if val := stacks[i].Peek(); val != "" {
-			topCrates += val
-		}
+	if input.HasFlag("render") {
+		fmt.Println(stacks.Render())
 	}
 
-	fmt.Printf("Part 1: %s\n", topCrates)
+	return stacks, stats
 }
 
-func PartTwo(stacks CrateStacks, instructions []*Instruction) {
-	// create a copy to not modify the original
-	stacks = stacks.GetCopy()
+func PartOne(stacks CrateStacks, instructions []*Instruction, atomic bool) {
+	stacks, _ = runCrane(stacks, instructions, &CrateMover9000{}, atomic)
+	fmt.Printf("Part 1: %s\n", stacks.TopCrates())
+}
 
-	topCrates := ""
+func PartTwo(stacks CrateStacks, instructions []*Instruction, atomic bool) {
+	stacks, _ = runCrane(stacks, instructions, &CrateMover9001{}, atomic)
+	fmt.Printf("Part 2: %s\n", stacks.TopCrates())
+}
 
-	for _, ins := range instructions {
-		items, ok := stacks[ins.From].PopN(ins.NumItems)
-		if ok {
-			stacks[ins.To].PushN(items)
-		}
+func RunCranes(stacks CrateStacks, instructions []*Instruction, cranes []Crane, atomic bool) {
+	for _, crane := range cranes {
+		result, stats := runCrane(stacks, instructions, crane, atomic)
+		fmt.Printf("Crane %s: %s (lifts: %d, crates: %d, cost: %d)\n",
+			crane.Name(), result.TopCrates(), stats.Lifts, stats.Crates, stats.Cost)
 	}
+}
 
-	numStacks := len(stacks)
-	for i := 1; i <= numStacks; i++ {
-		if val := stacks[i].Peek(); val != "" {
-			topCrates += val
+func ShowReplay(stacks CrateStacks, instructions []*Instruction, cranes []Crane, pos int) {
+	for _, crane := range cranes {
+		state, err := NewReplay(stacks, instructions, crane).StateAt(pos)
+		if err != nil {
+			log.Fatal(err)
 		}
+
+		fmt.Printf("After %d instructions (crane %s):\n%s\n", pos, crane.Name(), state.Render())
+	}
+}
+
+// ShowDiff compares the final stacks of the first crane against the ones of
+// every other crane.
+func ShowDiff(stacks CrateStacks, instructions []*Instruction, cranes []Crane) {
+	expected := stacks.GetCopy()
+	if _, err := expected.Run(instructions, cranes[0], false); err != nil {
+		log.Fatal(err)
 	}
 
-	fmt.Printf("Part 2: %s\n", topCrates)
+	for _, crane := range cranes[1:] {
+		actual := stacks.GetCopy()
+		if _, err := actual.Run(instructions, crane, false); err != nil {
+			log.Fatal(err)
+		}
+
+		fmt.Printf("Crane %s against %s:\n%s\n", cranes[0].Name(), crane.Name(), Diff(expected, actual))
+	}
 }
 
-func readInput() (CrateStacks, []*Instruction, error) {
-	stacks := make(CrateStacks, 0)
-	instructions := make([]*Instruction, 0)
+func ShowHeights(stacks CrateStacks, instructions []*Instruction) {
+	heights, err := NewReplay(stacks, instructions, &CrateMover9000{}).Heights()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	processStack := func(line string) error {
-		stackID := 1
-		for i := 0; i < len(line); i += 4 {
-			if line[i] == '[' && line[i+2] == ']' {
-				val := string(line[i+1])
-
-				s, ok := stacks[stackID]
-				if ok {
-					s.PushLeft(val)
-				} else {
-					stacks[stackID] = &Stack{val}
-				}
+	for _, id := range stacks.IDs() {
+		h := heights[id]
+		minH, maxH := h[0], h[0]
+		for _, v := range h {
+			if v < minH {
+				minH = v
+			}
+			if v > maxH {
+				maxH = v
 			}
-
-			stackID++
 		}
 
-		return nil
+		fmt.Printf("Stack %d: start %d, end %d, min %d, max %d\n", id, h[0], h[len(h)-1], minH, maxH)
 	}
+}
+
+var instructionRe = regexp.MustCompile(`^move (?P<n1>\d+) from (?P<n2>\d+) to (?P<n3>\d+)$`)
+
+func readInput() (CrateStacks, []*Instruction, error) {
+	drawing := make([]string, 0)
+	instructions := make([]*Instruction, 0)
 
 	processInstruction := func(line string) error {
-		re := regexp.MustCompile(`move (?P<n1>\d+) from (?P<n2>\d+) to (?P<n3>\d+)`)
-		match := re.FindStringSubmatch(line)
+		match := instructionRe.FindStringSubmatch(line)
 
 		if len(match) != 4 {
 			return fmt.Errorf("wrong instruction format: %s", line)
@@ -201,13 +275,19 @@ func readInput() (CrateStacks, []*Instruction, error) {
 			return processInstruction(line)
 		}
 
-		return processStack(line)
+		drawing = append(drawing, line)
+		return nil
 	}
 
 	if err := input.ReadLines(inputName, processLine); err != nil {
 		return nil, nil, fmt.Errorf("failed to read input: %w", err)
 	}
 
+	stacks, err := ParseDrawing(drawing)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse drawing: %w", err)
+	}
+
 	return stacks, instructions, nil
 }
 
@@ -217,6 +297,40 @@ func main() {
 		log.Fatal(err)
 	}
 
-	PartOne(stacks, instructions)
-	PartTwo(stacks, instructions)
+	atomic := input.HasFlag("atomic")
+
+	PartOne(stacks, instructions, atomic)
+	PartTwo(stacks, instructions, atomic)
+
+	cranes := []Crane{&CrateMover9000{}, &CrateMover9001{}}
+	if names, ok := input.FlagValue("crane"); ok {
+		cranes = make([]Crane, 0)
+		for _, name := range strings.Split(names, ",") {
+			crane, err := ParseCrane(name)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			cranes = append(cranes, crane)
+		}
+
+		RunCranes(stacks, instructions, cranes, atomic)
+	}
+
+	if posStr, ok := input.FlagValue("replay"); ok {
+		pos, err := strconv.Atoi(posStr)
+		if err != nil {
+			log.Fatalf("replay position is not a number: %s", posStr)
+		}
+
+		ShowReplay(stacks, instructions, cranes, pos)
+	}
+
+	if input.HasFlag("diff") {
+		ShowDiff(stacks, instructions, cranes)
+	}
+
+	if input.HasFlag("heights") {
+		ShowHeights(stacks, instructions)
+	}
 }
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+)
+
+// checkpointEvery is how many instructions there are between two full
+// snapshots of the stacks kept by a Replay.
+const checkpointEvery = 64
+
+// MoveEvent is an applied instruction, with enough information to revert it.
+type MoveEvent struct {
+	Instruction *Instruction
+	// Crates are the moved crates as they were in the origin stack, from
+	// bottom to top.
+	Crates []string
+}
+
+// Revert puts the moved crates back in the origin stack, in their original
+// order, whatever the crane did with them.
+func (e *MoveEvent) Revert(stacks CrateStacks) {
+	stacks[e.Instruction.To].PopN(len(e.Crates))
+	stacks[e.Instruction.From].PushN(e.Crates)
+}
+
+// Replay executes the instructions step by step, recording every move so the
+// stacks can be moved back and forth through the history.
+type Replay struct {
+	Instructions []*Instruction
+
+	crane  Crane
+	stacks CrateStacks
+	pos    int
+	// events holds every move recorded so far, also the ones after the
+	// current position, so going forward again does not need to check them
+	events      []*MoveEvent
+	checkpoints []CrateStacks
+}
+
+func NewReplay(stacks CrateStacks, instructions []*Instruction, crane Crane) *Replay {
+	return &Replay{
+		Instructions: instructions,
+		crane:        crane,
+		stacks:       stacks.GetCopy(),
+		events:       make([]*MoveEvent, 0, len(instructions)),
+		checkpoints:  []CrateStacks{stacks.GetCopy()},
+	}
+}
+
+// Position returns the number of instructions applied so far.
+func (r *Replay) Position() int {
+	return r.pos
+}
+
+// Stacks returns a copy of the stacks at the current position.
+func (r *Replay) Stacks() CrateStacks {
+	return r.stacks.GetCopy()
+}
+
+// Forward applies the next instruction. It returns false if all of them were
+// already applied.
+func (r *Replay) Forward() (bool, error) {
+	pos := r.pos
+	if pos >= len(r.Instructions) {
+		return false, nil
+	}
+
+	ins := r.Instructions[pos]
+
+	// already recorded, so it is known to be valid
+	if pos < len(r.events) {
+		if _, err := r.stacks.Apply(ins, r.crane); err != nil {
+			return false, &InstructionError{Index: pos, Instruction: ins, Err: err}
+		}
+
+		r.pos++
+		return true, nil
+	}
+
+	if err := r.stacks.Check(ins); err != nil {
+		return false, &InstructionError{Index: pos, Instruction: ins, Err: err}
+	}
+
+	from := *r.stacks[ins.From]
+	crates := make([]string, ins.NumItems)
+	copy(crates, from[len(from)-ins.NumItems:])
+
+	if _, err := r.stacks.Apply(ins, r.crane); err != nil {
+		return false, &InstructionError{Index: pos, Instruction: ins, Err: err}
+	}
+
+	r.events = append(r.events, &MoveEvent{Instruction: ins, Crates: crates})
+	r.pos++
+
+	if pos+1 == len(r.checkpoints)*checkpointEvery {
+		r.checkpoints = append(r.checkpoints, r.stacks.GetCopy())
+	}
+
+	return true, nil
+}
+
+// Back reverts the last applied instruction. It returns false if there is
+// nothing left to revert.
+func (r *Replay) Back() bool {
+	if r.pos == 0 {
+		return false
+	}
+
+	r.pos--
+	r.events[r.pos].Revert(r.stacks)
+
+	return true
+}
+
+// Seek moves to the state after applying the first n instructions, starting
+// from the closest checkpoint when that is nearer than the current position.
+func (r *Replay) Seek(n int) error {
+	if n < 0 || n > len(r.Instructions) {
+		return fmt.Errorf("position out of range: %d (%d instructions)", n, len(r.Instructions))
+	}
+
+	// checkpoints only exist for positions already recorded
+	if cpIdx := n / checkpointEvery; cpIdx < len(r.checkpoints) {
+		cpPos := cpIdx * checkpointEvery
+		if abs(n-cpPos) < abs(n-r.pos) {
+			r.stacks.Restore(r.checkpoints[cpIdx])
+			r.pos = cpPos
+		}
+	}
+
+	for r.pos > n {
+		r.Back()
+	}
+
+	for r.pos < n {
+		if _, err := r.Forward(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// StateAt returns a copy of the stacks after applying the first n
+// instructions.
+func (r *Replay) StateAt(n int) (CrateStacks, error) {
+	if err := r.Seek(n); err != nil {
+		return nil, err
+	}
+
+	return r.Stacks(), nil
+}
+
+// Heights replays every instruction and returns the height of each stack
+// over time, where the value at index i is the height after i instructions.
+func (r *Replay) Heights() (map[int][]int, error) {
+	if err := r.Seek(0); err != nil {
+		return nil, err
+	}
+
+	heights := make(map[int][]int, len(r.stacks))
+	record := func() {
+		for id, s := range r.stacks {
+			heights[id] = append(heights[id], len(*s))
+		}
+	}
+
+	record()
+	for {
+		ok, err := r.Forward()
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			break
+		}
+
+		record()
+	}
+
+	return heights, nil
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+)
+
+type SegmentKind int
+
+const (
+	// SegmentPreamble is what comes before the first marker.
+	SegmentPreamble SegmentKind = iota
+	// SegmentPacket is the payload after a marker, up to the next one.
+	SegmentPacket
+	// SegmentCorrupted is a stretch of the stream longer than the max gap
+	// without any marker.
+	SegmentCorrupted
+)
+
+func (k SegmentKind) String() string {
+	switch k {
+	case SegmentPreamble:
+		return "preamble"
+
+	case SegmentPacket:
+		return "packet"
+
+	case SegmentCorrupted:
+		return "corrupted"
+
+	default:
+		return fmt.Sprintf("unknown (%d)", int(k))
+	}
+}
+
+type Segment struct {
+	Kind SegmentKind
+	// Start and End are the positions of the first and last characters of
+	// the payload, where the stream starts at 1. End is Start-1 for empty
+	// payloads.
+	Start   int
+	End     int
+	Marker  string
+	Payload string
+}
+
+type DecoderStats struct {
+	Markers        int
+	Packets        int
+	Corrupted      int
+	CorruptedChars int
+	// MinSpacing and MaxSpacing are the shortest and longest distances
+	// between the end of a marker and the end of the next one.
+	MinSpacing   int
+	MaxSpacing   int
+	TotalSpacing int
+}
+
+func (s *DecoderStats) MeanSpacing() float64 {
+	if s.Markers < 2 {
+		return 0
+	}
+
+	return float64(s.TotalSpacing) / float64(s.Markers-1)
+}
+
+// Decoder splits a datastream into packets, each one starting after a marker
+// of MarkerLength different characters. Markers never overlap, so the next one
+// is only searched after the end of the previous one. Only the payload of the
+// current packet is kept in memory.
+type Decoder struct {
+	MarkerLength int
+	// MaxGap is the max number of characters allowed without a marker before
+	// they are reported as corrupted, or 0 for no limit.
+	MaxGap    int
+	OnSegment func(Segment)
+	Stats     DecoderStats
+
+	win        *window
+	pos        int
+	buf        []rune
+	bufStart   int
+	marker     string
+	lastMarker int
+	corrupted  bool
+}
+
+func NewDecoder(markerLength, maxGap int, onSegment func(Segment)) (*Decoder, error) {
+	if markerLength < 1 {
+		return nil, fmt.Errorf("invalid marker length: %d", markerLength)
+	}
+
+	if maxGap < 0 {
+		return nil, fmt.Errorf("invalid max gap: %d", maxGap)
+	}
+
+	return &Decoder{
+		MarkerLength: markerLength,
+		MaxGap:       maxGap,
+		OnSegment:    onSegment,
+		win:          newWindow(markerLength),
+		buf:          make([]rune, 0),
+		bufStart:     1,
+	}, nil
+}
+
+func (d *Decoder) Feed(char rune) {
+	d.pos++
+	d.buf = append(d.buf, char)
+	d.win.push(char)
+
+	if d.win.isUnique() {
+		d.foundMarker()
+		return
+	}
+
+	// MaxGap characters went by without a marker, and the ones after them
+	// are not enough to complete one yet. Long corrupted stretches are
+	// reported in chunks of MaxGap characters, so they are never kept in
+	// memory as a whole.
+	if d.MaxGap > 0 && len(d.buf) >= d.MaxGap+d.MarkerLength-1 {
+		d.emit(SegmentCorrupted, d.MaxGap)
+	}
+}
+
+// Close emits whatever is left after the last marker.
+func (d *Decoder) Close() {
+	switch {
+	case d.marker != "":
+		d.emit(SegmentPacket, len(d.buf))
+
+	case len(d.buf) > 0 && d.corrupted:
+		d.emit(SegmentCorrupted, len(d.buf))
+
+	case len(d.buf) > 0:
+		d.emit(SegmentPreamble, len(d.buf))
+	}
+}
+
+func (d *Decoder) foundMarker() {
+	payloadLen := len(d.buf) - d.MarkerLength
+
+	switch {
+	case d.marker != "":
+		d.emit(SegmentPacket, payloadLen)
+
+	case payloadLen > 0 && d.corrupted:
+		d.emit(SegmentCorrupted, payloadLen)
+
+	case payloadLen > 0:
+		d.emit(SegmentPreamble, payloadLen)
+	}
+
+	d.corrupted = false
+	d.marker = string(d.buf)
+	d.buf = d.buf[:0]
+	d.bufStart = d.pos + 1
+	d.win = newWindow(d.MarkerLength)
+
+	if d.Stats.Markers > 0 {
+		spacing := d.pos - d.lastMarker
+		if d.Stats.MinSpacing == 0 || spacing < d.Stats.MinSpacing {
+			d.Stats.MinSpacing = spacing
+		}
+		if spacing > d.Stats.MaxSpacing {
+			d.Stats.MaxSpacing = spacing
+		}
+		d.Stats.TotalSpacing += spacing
+	}
+
+	d.Stats.Markers++
+	d.lastMarker = d.pos
+}
+
+// emit reports the first n buffered characters as a segment of the given
+// kind and drops them from the buffer. Corrupted segments end the current
+// packet, since its payload can no longer be trusted.
+func (d *Decoder) emit(kind SegmentKind, n int) {
+	seg := Segment{
+		Kind:    kind,
+		Start:   d.bufStart,
+		End:     d.bufStart + n - 1,
+		Payload: string(d.buf[:n]),
+	}
+
+	switch kind {
+	case SegmentPacket:
+		seg.Marker = d.marker
+		d.Stats.Packets++
+
+	case SegmentCorrupted:
+		d.marker = ""
+		d.corrupted = true
+		d.Stats.Corrupted++
+		d.Stats.CorruptedChars += n
+	}
+
+	d.buf = append(d.buf[:0], d.buf[n:]...)
+	d.bufStart += n
+
+	d.OnSegment(seg)
+}
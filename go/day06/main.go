@@ -3,75 +3,175 @@ package main
 import (
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/rarguelloF/advent-of-code-2022/input"
 )
 
 const inputName = "day06"
 
-func isUniqueChars(foundChars map[rune]int) bool {
-	for _, count := range foundChars {
-		if count > 1 {
-			return false
+const (
+	packetMarkerLength  = 4
+	messageMarkerLength = 14
+)
+
+// readStream reads the datastream once, from stdin if -stdin was given,
+// passing every character to feed until it returns true.
+func readStream(feed func(char rune) bool) error {
+	processChar := func(char rune) (bool, error) {
+		// the datastream is a single line
+		if char == '\n' || char == '\r' {
+			return false, nil
 		}
+
+		return feed(char), nil
 	}
-	return true
+
+	if input.HasFlag("stdin") {
+		if err := input.ScanChars(os.Stdin, processChar); err != nil {
+			return fmt.Errorf("failed to read stdin: %w", err)
+		}
+
+		return nil
+	}
+
+	if err := input.ReadChars(inputName, processChar); err != nil {
+		return fmt.Errorf("failed to read input: %w", err)
+	}
+
+	return nil
+}
+
+func PartOne(firstMarkers map[int]int) {
+	fmt.Printf("Part 1: %d\n", firstMarkers[packetMarkerLength])
 }
 
-func findFirstUniqueSequenceStart(seqLength int) (int, error) {
-	idx := -1
-	curIdx := 1
-	chars := make([]rune, 0, seqLength)
-	foundChars := make(map[rune]int, 0)
+func PartTwo(firstMarkers map[int]int) {
+	fmt.Printf("Part 2: %d\n", firstMarkers[messageMarkerLength])
+}
 
-	processChar := func(char rune) (bool, error) {
-		if len(chars) >= seqLength {
-			first := chars[0]
-			if foundChars[first] == 1 {
-				delete(foundChars, first)
-			} else {
-				foundChars[first]--
-			}
-			chars = chars[1:]
+func printDecoderStats(name string, d *Decoder) {
+	fmt.Printf("%s (marker length %d):\n", name, d.MarkerLength)
+	fmt.Printf("  markers: %d, packets: %d\n", d.Stats.Markers, d.Stats.Packets)
+	fmt.Printf("  corrupted segments: %d (%d characters)\n", d.Stats.Corrupted, d.Stats.CorruptedChars)
+	fmt.Printf("  marker spacing: min %d, max %d, mean %.2f\n", d.Stats.MinSpacing, d.Stats.MaxSpacing, d.Stats.MeanSpacing())
+}
+
+func newDecoders() ([]*Decoder, error) {
+	maxGap := 0
+	if maxGapStr, ok := input.FlagValue("max-gap"); ok {
+		n, err := strconv.Atoi(maxGapStr)
+		if err != nil {
+			return nil, fmt.Errorf("max gap is not a number: %s", maxGapStr)
 		}
 
-		chars = append(chars, char)
-		foundChars[char]++
-		if len(chars) >= seqLength && isUniqueChars(foundChars) {
-			idx = curIdx
-			return true, nil
+		maxGap = n
+	}
+
+	verbose := input.HasFlag("verbose")
+	decoders := make([]*Decoder, 0, 2)
+
+	for _, l := range []int{packetMarkerLength, messageMarkerLength} {
+		l := l
+		d, err := NewDecoder(l, maxGap, func(seg Segment) {
+			if verbose {
+				fmt.Printf("[%d] %s %d-%d %q: %q\n", l, seg.Kind, seg.Start, seg.End, seg.Marker, seg.Payload)
+			}
+		})
+		if err != nil {
+			return nil, err
 		}
 
-		curIdx++
-		return false, nil
+		decoders = append(decoders, d)
 	}
 
-	if err := input.ReadChars(inputName, processChar); err != nil {
-		return -1, fmt.Errorf("failed to read input: %w", err)
+	return decoders, nil
+}
+
+func readMarkerLengths() ([]int, error) {
+	lengthsStr, ok := input.FlagValue("markers")
+	if !ok {
+		return nil, nil
 	}
 
-	return idx, nil
+	lengths := make([]int, 0)
+	for _, s := range strings.Split(lengthsStr, ",") {
+		l, err := strconv.Atoi(s)
+		if err != nil || l < 1 {
+			return nil, fmt.Errorf("invalid marker length: %s", s)
+		}
+
+		lengths = append(lengths, l)
+	}
+
+	return lengths, nil
 }
 
-func PartOne() {
-	startIdx, err := findFirstUniqueSequenceStart(4)
+func main() {
+	reportLengths, err := readMarkerLengths()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	fmt.Printf("Part 1: %d\n", startIdx)
-}
+	// when reporting every marker or decoding the whole stream is read,
+	// otherwise it stops once the first marker of each part is found
+	report := make(map[int]bool, len(reportLengths))
+	lengths := []int{packetMarkerLength, messageMarkerLength}
 
-func PartTwo() {
-	startIdx, err := findFirstUniqueSequenceStart(14)
+	for _, l := range reportLengths {
+		if l != packetMarkerLength && l != messageMarkerLength && !report[l] {
+			lengths = append(lengths, l)
+		}
+
+		report[l] = true
+	}
+	firstMarkers := make(map[int]int, len(lengths))
+
+	decode := input.HasFlag("decode")
+
+	var decoders []*Decoder
+	if decode {
+		decoders, err = newDecoders()
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	scanner := NewMarkerScanner(lengths, func(m Marker) {
+		if _, ok := firstMarkers[m.Length]; !ok {
+			firstMarkers[m.Length] = m.Position
+		}
+
+		if report[m.Length] {
+			fmt.Printf("Marker of length %d at %d\n", m.Length, m.Position)
+		}
+	})
+
+	err = readStream(func(char rune) bool {
+		scanner.Feed(char)
+		for _, d := range decoders {
+			d.Feed(char)
+		}
+
+		return !decode && len(report) == 0 &&
+			firstMarkers[packetMarkerLength] > 0 &&
+			firstMarkers[messageMarkerLength] > 0
+	})
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	fmt.Printf("Part 2: %d\n", startIdx)
-}
+	for _, d := range decoders {
+		d.Close()
+	}
 
-func main() {
-	PartOne()
-	PartTwo()
+	PartOne(firstMarkers)
+	PartTwo(firstMarkers)
+
+	if decode {
+		printDecoderStats("Packets", decoders[0])
+		printDecoderStats("Messages", decoders[1])
+	}
 }
@@ -0,0 +1,95 @@
+package main
+
+// window keeps the last characters of the stream in a ring buffer, along
+// with how many times each of them appears and how many of them are repeated,
+// so checking whether they are all different takes constant time whatever
+// the window length.
+type window struct {
+	length     int
+	chars      []rune
+	next       int
+	full       bool
+	foundChars map[rune]int
+	repeated   int
+}
+
+func newWindow(length int) *window {
+	return &window{
+		length:     length,
+		chars:      make([]rune, length),
+		foundChars: make(map[rune]int, length),
+	}
+}
+
+func (w *window) push(char rune) {
+	if w.full {
+		first := w.chars[w.next]
+		w.foundChars[first]--
+
+		switch w.foundChars[first] {
+		case 0:
+			delete(w.foundChars, first)
+
+		case 1:
+			w.repeated--
+		}
+	}
+
+	w.chars[w.next] = char
+	w.next = (w.next + 1) % w.length
+	if w.next == 0 {
+		w.full = true
+	}
+
+	w.foundChars[char]++
+	if w.foundChars[char] == 2 {
+		w.repeated++
+	}
+}
+
+// isUnique reports whether the window is full and all its characters are
+// different.
+func (w *window) isUnique() bool {
+	return w.full && w.repeated == 0
+}
+
+// Marker is a window of Length different characters ending at Position,
+// where the first character of the stream is at position 1.
+type Marker struct {
+	Length   int
+	Position int
+}
+
+// MarkerScanner looks for markers of several lengths at once in a single pass
+// over the stream, keeping only the last characters of each window.
+type MarkerScanner struct {
+	windows  []*window
+	pos      int
+	onMarker func(Marker)
+}
+
+func NewMarkerScanner(lengths []int, onMarker func(Marker)) *MarkerScanner {
+	s := &MarkerScanner{
+		windows:  make([]*window, 0, len(lengths)),
+		onMarker: onMarker,
+	}
+
+	for _, l := range lengths {
+		s.windows = append(s.windows, newWindow(l))
+	}
+
+	return s
+}
+
+// Feed adds the next character of the stream, calling onMarker for every
+// window that becomes all different with it.
+func (s *MarkerScanner) Feed(char rune) {
+	s.pos++
+
+	for _, w := range s.windows {
+		w.push(char)
+		if w.isUnique() {
+			s.onMarker(Marker{Length: w.length, Position: s.pos})
+		}
+	}
+}
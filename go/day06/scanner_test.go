@@ -0,0 +1,87 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// randomStream returns n characters out of an alphabet of the given size.
+func randomStream(r *rand.Rand, n, alphabet int) []rune {
+	stream := make([]rune, n)
+	for i := range stream {
+		stream[i] = rune('a' + r.Intn(alphabet))
+	}
+
+	return stream
+}
+
+// allDifferent is the straightforward check the window replaces.
+func allDifferent(chars []rune) bool {
+	seen := make(map[rune]bool, len(chars))
+	for _, c := range chars {
+		if seen[c] {
+			return false
+		}
+
+		seen[c] = true
+	}
+
+	return true
+}
+
+func TestMarkerScannerMatchesBruteForce(t *testing.T) {
+	r := rand.New(rand.NewSource(2022))
+	lengths := []int{1, 4, 14, 30}
+
+	for i := 0; i < 50; i++ {
+		stream := randomStream(r, 2000, 20+r.Intn(20))
+
+		found := make(map[Marker]bool, 0)
+		s := NewMarkerScanner(lengths, func(m Marker) {
+			found[m] = true
+		})
+
+		for _, c := range stream {
+			s.Feed(c)
+		}
+
+		for _, l := range lengths {
+			for pos := l; pos <= len(stream); pos++ {
+				m := Marker{Length: l, Position: pos}
+				if want := allDifferent(stream[pos-l : pos]); found[m] != want {
+					t.Fatalf("marker of length %d at %d: got %t, want %t", l, pos, found[m], want)
+				}
+			}
+		}
+	}
+}
+
+// BenchmarkMarkerScanner feeds the same stream with windows of growing
+// lengths. The speed should stay about the same, since the window does
+// not look at all its characters on every push.
+func BenchmarkMarkerScanner(b *testing.B) {
+	const streamLen = 1 << 16
+
+	r := rand.New(rand.NewSource(2022))
+	// enough different characters for the longest windows to find markers
+	stream := randomStream(r, streamLen, 2000)
+
+	for _, length := range []int{4, 14, 100, 500} {
+		b.Run(fmt.Sprintf("length=%d", length), func(b *testing.B) {
+			// every character counts as a byte, so the speed shows up in MB/s
+			b.SetBytes(streamLen)
+			markers := 0
+
+			for i := 0; i < b.N; i++ {
+				s := NewMarkerScanner([]int{length}, func(Marker) {
+					markers++
+				})
+
+				for _, c := range stream {
+					s.Feed(c)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type ConflictPolicy int
+
+const (
+	// PolicyLastWins keeps what the latest listing says.
+	PolicyLastWins ConflictPolicy = iota
+	// PolicyFirstWins keeps what the first listing said, ignoring later
+	// changes.
+	PolicyFirstWins
+	// PolicyError stops at the first conflict.
+	PolicyError
+)
+
+func ParseConflictPolicy(s string) (ConflictPolicy, error) {
+	switch s {
+	case "last":
+		return PolicyLastWins, nil
+
+	case "first":
+		return PolicyFirstWins, nil
+
+	case "error":
+		return PolicyError, nil
+
+	default:
+		return 0, fmt.Errorf("unknown conflict policy (expected last, first or error): %s", s)
+	}
+}
+
+type ConflictKind int
+
+const (
+	// ConflictMalformed is a listing line that cannot be parsed.
+	ConflictMalformed ConflictKind = iota
+	// ConflictSizeChanged is a file listed again with another size.
+	ConflictSizeChanged
+	// ConflictTypeChanged is an entry listed as a file and then as a dir, or
+	// the other way around.
+	ConflictTypeChanged
+	// ConflictMissing is an entry not listed again when its dir is.
+	ConflictMissing
+	// ConflictAdded is an entry that was not there when its dir was listed
+	// before.
+	ConflictAdded
+)
+
+func (k ConflictKind) String() string {
+	switch k {
+	case ConflictMalformed:
+		return "malformed line"
+
+	case ConflictSizeChanged:
+		return "size changed"
+
+	case ConflictTypeChanged:
+		return "type changed"
+
+	case ConflictMissing:
+		return "missing entry"
+
+	case ConflictAdded:
+		return "added entry"
+
+	default:
+		return fmt.Sprintf("unknown (%d)", int(k))
+	}
+}
+
+// Conflict is a listing that does not agree with an earlier one, or that
+// cannot be read at all.
+type Conflict struct {
+	Kind ConflictKind
+	// Line is the transcript line where the conflict was found, and PrevLine
+	// the one it disagrees with, or 0 if there is none.
+	Line     int
+	PrevLine int
+	Path     string
+	Detail   string
+}
+
+func (c *Conflict) Error() string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "line %d: %s", c.Line, c.Kind)
+	if c.Path != "" {
+		fmt.Fprintf(&sb, ": %s", c.Path)
+	}
+
+	if c.Detail != "" {
+		fmt.Fprintf(&sb, " (%s)", c.Detail)
+	}
+
+	if c.PrevLine > 0 {
+		fmt.Fprintf(&sb, ", listed at line %d", c.PrevLine)
+	}
+
+	return sb.String()
+}
+
+// listedEntry is an entry as a listing showed it.
+type listedEntry struct {
+	isDir bool
+	size  int64
+	line  int
+}
+
+func (e listedEntry) sameAs(other listedEntry) bool {
+	return e.isDir == other.isDir && e.size == other.size
+}
+
+func (e listedEntry) String() string {
+	if e.isDir {
+		return "dir"
+	}
+
+	return fmt.Sprintf("%d bytes", e.size)
+}
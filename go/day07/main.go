@@ -1,57 +1,31 @@
 package main
 
 import (
-	"errors"
+	"encoding/json"
 	"fmt"
 	"log"
-	"math"
 	"strconv"
-	"strings"
 
 	"github.com/rarguelloF/advent-of-code-2022/input"
+	"github.com/rarguelloF/advent-of-code-2022/vfs"
 )
 
-const inputName = "day07"
-
-type File struct {
-	Name string
-	Size int64
-}
-
-type Dir struct {
-	Name   string
-	Parent *Dir
-	Files  map[string]*File
-	Dirs   map[string]*Dir
-}
-
-func (d *Dir) Size() (r int64) {
-	for _, f := range d.Files {
-		r += f.Size
-	}
-
-	for _, sd := range d.Dirs {
-		r += sd.Size()
-	}
-
-	return r
-}
+const (
+	inputName = "day07"
+	// defaultTop is how many dirs and files the JSON report ranks when -top is
+	// not given
+	defaultTop = 10
 
-func newDir(name string, parent *Dir) *Dir {
-	return &Dir{
-		Name:   name,
-		Parent: parent,
-		Files:  make(map[string]*File, 0),
-		Dirs:   make(map[string]*Dir, 0),
-	}
-}
+	defaultDiskSize   = 70_000_000
+	defaultTargetFree = 30_000_000
+)
 
-func PartOne(rootDir *Dir) {
+func PartOne(rootDir *vfs.Dir) {
 	const maxSize = 100_000
 
 	sumSizes := int64(0)
 
-	dirs := []*Dir{rootDir}
+	dirs := []*vfs.Dir{rootDir}
 
 	for len(dirs) > 0 {
 		d := dirs[0]
@@ -61,140 +35,199 @@ func PartOne(rootDir *Dir) {
 			sumSizes += s
 		}
 
-		for _, sd := range d.Dirs {
-			dirs = append(dirs, sd)
-		}
+		dirs = append(dirs, d.Dirs()...)
 	}
 
 	fmt.Printf("Part 1: %d\n", sumSizes)
 }
 
-func PartTwo(rootDir *Dir) {
-	const (
-		availableDisk = 70_000_000
-		minUnused     = 30_000_000
-	)
+// PartTwo finds the smallest single dir that frees enough space for the
+// update.
+func PartTwo(rootDir *vfs.Dir, planner *Planner) {
+	candidates, err := planner.Candidates(rootDir)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	totalSize := rootDir.Size()
-	curFreeSize := availableDisk - totalSize
-	chosenDeleteSize := int64(math.MaxInt64)
+	for _, c := range candidates {
+		if c.IsDir {
+			fmt.Printf("Part 2: %d\n", c.Size)
+			return
+		}
+	}
 
-	dirs := []*Dir{rootDir}
+	log.Fatal("no dir frees enough space")
+}
 
-	for len(dirs) > 0 {
-		d := dirs[0]
-		dirs = dirs[1:]
+func readPlanner() (*Planner, error) {
+	planner := &Planner{DiskSize: defaultDiskSize, TargetFree: defaultTargetFree}
 
-		s := d.Size()
+	flags := []struct {
+		name  string
+		value *int64
+	}{
+		{name: "disk-size", value: &planner.DiskSize},
+		{name: "target-free", value: &planner.TargetFree},
+	}
 
-		if s+curFreeSize >= minUnused && s < chosenDeleteSize {
-			chosenDeleteSize = s
+	for _, flag := range flags {
+		str, ok := input.FlagValue(flag.name)
+		if !ok {
+			continue
 		}
 
-		for _, sd := range d.Dirs {
-			dirs = append(dirs, sd)
+		n, err := strconv.ParseInt(str, 10, 64)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("%s is not a valid size: %s", flag.name, str)
 		}
+
+		*flag.value = n
 	}
 
-	fmt.Printf("Part 2: %d\n", chosenDeleteSize)
+	return planner, nil
 }
 
-const (
-	CommandChangeDir = "cd"
-	CommandList      = "ls"
-)
+func readInput() (*Shell, error) {
+	sh := NewShell()
 
-func readInput() (*Dir, error) {
-	rootDir := newDir("/", nil)
+	if policyStr, ok := input.FlagValue("conflicts"); ok {
+		policy, err := ParseConflictPolicy(policyStr)
+		if err != nil {
+			return nil, err
+		}
 
-	curDir := rootDir
-	listingDir := false
+		sh.Policy = policy
+	}
 
-	processLine := func(line string) error {
-		if len(line) == 0 {
-			return errors.New("found empty line")
-		}
+	if err := input.ReadLines(inputName, sh.RunLine); err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
 
-		if line[0] == '$' {
-			listingDir = false
-			cmdAndArgs := strings.Split(line[2:], " ")
+	if err := sh.Close(); err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
 
-			cmd := cmdAndArgs[0]
-			switch cmd {
-			case CommandList:
-				listingDir = true
+	return sh, nil
+}
 
-			case CommandChangeDir:
-				targetDir := cmdAndArgs[1]
+func showPlan(rootDir *vfs.Dir, planner *Planner) {
+	plan, err := planner.Plan(rootDir)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-				switch targetDir {
-				case "/":
-					curDir = rootDir
+	candidates, err := planner.Candidates(rootDir)
+	if err != nil {
+		log.Fatal(err)
+	}
 
-				case "..":
-					if curDir.Parent == nil {
-						return fmt.Errorf("cannot go to previous dir since current dir does not have a parent: %+v", curDir)
-					}
+	fmt.Printf("Need to delete %d, the plan deletes %d:\n", plan.Needed, plan.Freed)
+	if !plan.Exact {
+		fmt.Println("(tree too big for the exact search, only single candidates were considered)")
+	}
 
-					curDir = curDir.Parent
+	for _, c := range plan.Delete {
+		fmt.Printf("%d\t%s\n", c.Size, c.Path)
+	}
 
-				default:
-					dir, ok := curDir.Dirs[targetDir]
-					if !ok {
-						return fmt.Errorf("dir not found or did not run ls before: %s", targetDir)
-					}
+	fmt.Println("Single candidates:")
+	for i, c := range candidates {
+		fmt.Printf("%d.\t%d\t%s\n", i+1, c.Size, c.Path)
+	}
 
-					curDir = dir
-				}
+	if input.HasFlag("dry-run") {
+		fmt.Print(DryRun(rootDir, plan))
+	}
+}
 
-			default:
-				return fmt.Errorf("unknown command: %s", cmd)
-			}
+func main() {
+	sh, err := readInput()
+	if err != nil {
+		log.Fatal(err)
+	}
 
-			return nil
+	if input.HasFlag("check") {
+		for _, c := range sh.Conflicts {
+			fmt.Println(c)
 		}
 
-		if listingDir {
-			data := strings.Split(line, " ")
-
-			if data[0] == "dir" {
-				dirName := data[1]
-
-				if _, ok := curDir.Dirs[dirName]; !ok {
-					curDir.Dirs[dirName] = newDir(dirName, curDir)
-				}
-			} else {
-				sizeStr, fileName := data[0], data[1]
-				size, err := strconv.ParseInt(sizeStr, 10, 64)
-				if err != nil {
-					return fmt.Errorf("expected a number as first parameter: %s", line)
-				}
-
-				curDir.Files[fileName] = &File{
-					Name: fileName,
-					Size: size,
-				}
-			}
+		fmt.Printf("%d conflicts found\n", len(sh.Conflicts))
+	}
+
+	rootDir := sh.FS.Root()
 
-			return nil
+	if input.HasFlag("json") {
+		top := defaultTop
+		if topStr, ok := input.FlagValue("top"); ok {
+			if top, err = strconv.Atoi(topStr); err != nil {
+				log.Fatalf("top is not a number: %s", topStr)
+			}
 		}
 
-		return fmt.Errorf("unexpected line: %s", line)
-	}
+		out, err := json.MarshalIndent(NewReport(rootDir, top), "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
 
-	if err := input.ReadLines(inputName, processLine); err != nil {
-		return nil, fmt.Errorf("failed to read input: %w", err)
+		fmt.Println(string(out))
+		return
 	}
 
-	return rootDir, nil
-}
-
-func main() {
-	rootDir, err := readInput()
+	planner, err := readPlanner()
 	if err != nil {
 		log.Fatal(err)
 	}
 
 	PartOne(rootDir)
-	PartTwo(rootDir)
+	PartTwo(rootDir, planner)
+
+	if input.HasFlag("plan") || input.HasFlag("dry-run") {
+		showPlan(rootDir, planner)
+	}
+
+	if input.HasFlag("tree") {
+		fmt.Print(RenderTree(rootDir))
+	}
+
+	if topStr, ok := input.FlagValue("top"); ok {
+		top, err := strconv.Atoi(topStr)
+		if err != nil {
+			log.Fatalf("top is not a number: %s", topStr)
+		}
+
+		fmt.Println("Largest dirs:")
+		for _, u := range LargestDirs(rootDir, top) {
+			fmt.Printf("%s\t%s\n", HumanSize(u.Size), u.Path)
+		}
+
+		fmt.Println("Largest files:")
+		for _, u := range LargestFiles(rootDir, top) {
+			fmt.Printf("%s\t%s\n", HumanSize(u.Size), u.Path)
+		}
+	}
+
+	if input.HasFlag("extensions") {
+		fmt.Println("Extensions:")
+		for _, e := range ExtensionTotals(rootDir) {
+			fmt.Printf("%s\t%d files\t%s\n", HumanSize(e.Size), e.Files, e.Extension)
+		}
+	}
+
+	if pattern, ok := input.FlagValue("glob"); ok {
+		fsys := vfs.FromDir(rootDir)
+
+		matches, err := fsys.Glob(pattern)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		for _, m := range matches {
+			info, err := fsys.Stat(m)
+			if err != nil {
+				log.Fatal(err)
+			}
+
+			fmt.Printf("%d\t%s\n", info.Size(), m)
+		}
+	}
 }
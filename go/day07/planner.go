@@ -0,0 +1,285 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/rarguelloF/advent-of-code-2022/vfs"
+)
+
+// maxPlanSums is the largest number of sizes the exact planner keeps track of.
+// Bigger searches only look at single candidates.
+const maxPlanSums = 1 << 25
+
+var ErrNotEnoughSpace = errors.New("not enough space even deleting everything")
+
+// Planner chooses what to delete to get TargetFree free space on a disk of
+// DiskSize.
+type Planner struct {
+	DiskSize   int64
+	TargetFree int64
+}
+
+// Candidate is a file or a dir that can be deleted.
+type Candidate struct {
+	Path  string
+	Size  int64
+	IsDir bool
+}
+
+type Plan struct {
+	// Needed is how much has to be deleted, and Freed how much the plan
+	// deletes.
+	Needed int64
+	Freed  int64
+	Delete []Candidate
+	// Exact is false when the tree was too big for the exact search and the
+	// plan only deletes the best single candidate.
+	Exact bool
+}
+
+// Needed returns how much has to be deleted from the tree to reach the
+// target free space.
+func (p *Planner) Needed(root *vfs.Dir) (int64, error) {
+	if root.Size() > p.DiskSize {
+		return 0, fmt.Errorf("tree size %d does not fit in a disk of %d", root.Size(), p.DiskSize)
+	}
+
+	needed := p.TargetFree - (p.DiskSize - root.Size())
+	if needed < 0 {
+		needed = 0
+	}
+
+	if needed > root.Size() {
+		return 0, fmt.Errorf("%w: need %d, tree size is %d", ErrNotEnoughSpace, needed, root.Size())
+	}
+
+	return needed, nil
+}
+
+// Candidates returns every dir and file that reaches the target by itself,
+// smallest first and then by path.
+func (p *Planner) Candidates(root *vfs.Dir) ([]Candidate, error) {
+	needed, err := p.Needed(root)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]Candidate, 0)
+	walkTree(
+		root,
+		func(d *vfs.Dir) {
+			if d.Size() >= needed {
+				candidates = append(candidates, Candidate{Path: d.Path(), Size: d.Size(), IsDir: true})
+			}
+		},
+		func(f *vfs.File) {
+			if f.Size() >= needed {
+				candidates = append(candidates, Candidate{Path: f.Path(), Size: f.Size()})
+			}
+		},
+	)
+
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Size != candidates[j].Size {
+			return candidates[i].Size < candidates[j].Size
+		}
+
+		return candidates[i].Path < candidates[j].Path
+	})
+
+	return candidates, nil
+}
+
+// Plan finds the set of files and dirs, none of them inside another, with the
+// smallest total size that reaches the target. Deleting a dir is the same as
+// deleting all the files below it, so it searches the smallest sum of file
+// sizes that reaches the target, and then replaces the files by their dir
+// whenever the whole dir goes.
+func (p *Planner) Plan(root *vfs.Dir) (*Plan, error) {
+	needed, err := p.Needed(root)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Needed: needed, Delete: make([]Candidate, 0), Exact: true}
+	if needed == 0 {
+		return plan, nil
+	}
+
+	files := make([]*vfs.File, 0)
+	maxSize := int64(0)
+	walkTree(root, func(*vfs.Dir) {}, func(f *vfs.File) {
+		if f.Size() > 0 {
+			files = append(files, f)
+		}
+
+		if f.Size() > maxSize {
+			maxSize = f.Size()
+		}
+	})
+
+	// the best sum is below needed+maxSize, otherwise one of its files could
+	// be dropped
+	limit := needed + maxSize - 1
+	if limit > root.Size() {
+		limit = root.Size()
+	}
+
+	if limit >= maxPlanSums {
+		candidates, err := p.Candidates(root)
+		if err != nil {
+			return nil, err
+		}
+
+		plan.Exact = false
+		plan.Freed = candidates[0].Size
+		plan.Delete = append(plan.Delete, candidates[0])
+		return plan, nil
+	}
+
+	chosen := smallestSumAtLeast(files, needed, limit)
+
+	_, plan.Delete = collapse(root, chosen)
+	for _, c := range plan.Delete {
+		plan.Freed += c.Size
+	}
+
+	return plan, nil
+}
+
+// smallestSumAtLeast returns the files with the smallest total size that is
+// at least min, knowing that it is at most limit. The sizes reached so far are
+// kept in a bitset, and via holds the 1-based index of the file that first
+// reached each size, so the chosen files can be found going backwards.
+func smallestSumAtLeast(files []*vfs.File, min, limit int64) map[*vfs.File]bool {
+	words := int(limit/64) + 1
+	reached := make([]uint64, words)
+	reached[0] = 1
+	via := make([]int32, limit+1)
+
+	lastMask := ^uint64(0)
+	if r := (limit + 1) % 64; r != 0 {
+		lastMask = 1<<uint(r) - 1
+	}
+
+	for i, f := range files {
+		wordShift, bitShift := int(f.Size()/64), uint(f.Size()%64)
+
+		// go downwards so every size is reached with each file at most once
+		for w := words - 1; w >= wordShift; w-- {
+			j := w - wordShift
+			shifted := reached[j] << bitShift
+			if bitShift > 0 && j > 0 {
+				shifted |= reached[j-1] >> (64 - bitShift)
+			}
+
+			added := shifted &^ reached[w]
+			if w == words-1 {
+				added &= lastMask
+			}
+
+			reached[w] |= added
+			for added != 0 {
+				b := bits.TrailingZeros64(added)
+				via[w*64+b] = int32(i + 1)
+				added &= added - 1
+			}
+		}
+	}
+
+	chosen := make(map[*vfs.File]bool, 0)
+
+	sum := min
+	for reached[sum/64]&(1<<uint(sum%64)) == 0 {
+		sum++
+	}
+
+	for sum > 0 {
+		f := files[via[sum]-1]
+		chosen[f] = true
+		sum -= f.Size()
+	}
+
+	return chosen
+}
+
+// collapse returns the candidates to delete the chosen files below the dir,
+// and whether they delete all of it.
+func collapse(d *vfs.Dir, chosen map[*vfs.File]bool) (bool, []Candidate) {
+	whole := true
+	parts := make([]Candidate, 0)
+
+	for _, sd := range d.Dirs() {
+		sdWhole, sdParts := collapse(sd, chosen)
+		whole = whole && sdWhole
+		parts = append(parts, sdParts...)
+	}
+
+	for _, f := range d.Files() {
+		if chosen[f] {
+			parts = append(parts, Candidate{Path: f.Path(), Size: f.Size()})
+		} else if f.Size() > 0 {
+			whole = false
+		}
+	}
+
+	if !whole {
+		return false, parts
+	}
+
+	// empty dirs are only deleted along with their parents
+	if d.Size() == 0 {
+		return true, nil
+	}
+
+	return true, []Candidate{{Path: d.Path(), Size: d.Size(), IsDir: true}}
+}
+
+// DryRun draws the tree as it would be after the plan, marking with - the
+// deleted entries and showing how the size of the remaining dirs changes.
+func DryRun(root *vfs.Dir, plan *Plan) string {
+	deleted := make(map[string]bool, len(plan.Delete))
+	for _, c := range plan.Delete {
+		deleted[c.Path] = true
+	}
+
+	var sb strings.Builder
+	dryRunNode(&sb, newNode(root), deleted, "")
+
+	return sb.String()
+}
+
+// dryRunNode draws a node and its children, and returns how much of it is
+// deleted.
+func dryRunNode(sb *strings.Builder, n *Node, deleted map[string]bool, indent string) int64 {
+	name := n.Name
+	if n.IsDir && n.Path != "/" {
+		name += "/"
+	}
+
+	if deleted[n.Path] {
+		fmt.Fprintf(sb, "- %s%s (%s)\n", indent, name, HumanSize(n.Size))
+		return n.Size
+	}
+
+	// the children go first to know the new size of the dir
+	var children strings.Builder
+	freed := int64(0)
+	for _, child := range n.Children {
+		freed += dryRunNode(&children, child, deleted, indent+"  ")
+	}
+
+	size := HumanSize(n.Size)
+	if freed > 0 {
+		size += " -> " + HumanSize(n.Size-freed)
+	}
+
+	fmt.Fprintf(sb, "  %s%s (%s)\n", indent, name, size)
+	sb.WriteString(children.String())
+
+	return freed
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rarguelloF/advent-of-code-2022/vfs"
+)
+
+const noExtension = "(none)"
+
+// Node is a file or a dir of the tree, as written in the JSON report.
+type Node struct {
+	Name     string  `json:"name"`
+	Path     string  `json:"path"`
+	Size     int64   `json:"size"`
+	IsDir    bool    `json:"is_dir"`
+	Children []*Node `json:"children,omitempty"`
+}
+
+type Usage struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+type ExtensionUsage struct {
+	Extension string `json:"extension"`
+	Files     int    `json:"files"`
+	Size      int64  `json:"size"`
+}
+
+type Report struct {
+	Tree         *Node            `json:"tree"`
+	LargestDirs  []Usage          `json:"largest_dirs"`
+	LargestFiles []Usage          `json:"largest_files"`
+	Extensions   []ExtensionUsage `json:"extensions"`
+}
+
+func NewReport(root *vfs.Dir, top int) *Report {
+	return &Report{
+		Tree:         newNode(root),
+		LargestDirs:  LargestDirs(root, top),
+		LargestFiles: LargestFiles(root, top),
+		Extensions:   ExtensionTotals(root),
+	}
+}
+
+func newNode(d *vfs.Dir) *Node {
+	n := &Node{Name: d.Name(), Path: d.Path(), Size: d.Size(), IsDir: true}
+
+	for _, sd := range d.Dirs() {
+		n.Children = append(n.Children, newNode(sd))
+	}
+
+	for _, f := range d.Files() {
+		n.Children = append(n.Children, &Node{Name: f.Name(), Path: f.Path(), Size: f.Size()})
+	}
+
+	return n
+}
+
+// walkTree calls the funcs for every dir and file below root, root included.
+func walkTree(root *vfs.Dir, onDir func(*vfs.Dir), onFile func(*vfs.File)) {
+	dirs := []*vfs.Dir{root}
+
+	for len(dirs) > 0 {
+		d := dirs[0]
+		dirs = dirs[1:]
+
+		onDir(d)
+		for _, f := range d.Files() {
+			onFile(f)
+		}
+
+		dirs = append(dirs, d.Dirs()...)
+	}
+}
+
+// largest sorts the usages by size, biggest first and then by path, and keeps
+// the first n of them, or all of them if n is not positive.
+func largest(usages []Usage, n int) []Usage {
+	sort.Slice(usages, func(i, j int) bool {
+		if usages[i].Size != usages[j].Size {
+			return usages[i].Size > usages[j].Size
+		}
+
+		return usages[i].Path < usages[j].Path
+	})
+
+	if n > 0 && len(usages) > n {
+		usages = usages[:n]
+	}
+
+	return usages
+}
+
+func LargestDirs(root *vfs.Dir, n int) []Usage {
+	usages := make([]Usage, 0)
+	walkTree(
+		root,
+		func(d *vfs.Dir) { usages = append(usages, Usage{Path: d.Path(), Size: d.Size()}) },
+		func(*vfs.File) {},
+	)
+
+	return largest(usages, n)
+}
+
+func LargestFiles(root *vfs.Dir, n int) []Usage {
+	usages := make([]Usage, 0)
+	walkTree(
+		root,
+		func(*vfs.Dir) {},
+		func(f *vfs.File) { usages = append(usages, Usage{Path: f.Path(), Size: f.Size()}) },
+	)
+
+	return largest(usages, n)
+}
+
+// ExtensionTotals adds up the files by extension, biggest total first.
+func ExtensionTotals(root *vfs.Dir) []ExtensionUsage {
+	totals := make(map[string]*ExtensionUsage, 0)
+	walkTree(
+		root,
+		func(*vfs.Dir) {},
+		func(f *vfs.File) {
+			ext := path.Ext(f.Name())
+			if ext == "" {
+				ext = noExtension
+			}
+
+			if _, ok := totals[ext]; !ok {
+				totals[ext] = &ExtensionUsage{Extension: ext}
+			}
+
+			totals[ext].Files++
+			totals[ext].Size += f.Size()
+		},
+	)
+
+	result := make([]ExtensionUsage, 0, len(totals))
+	for _, t := range totals {
+		result = append(result, *t)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Size != result[j].Size {
+			return result[i].Size > result[j].Size
+		}
+
+		return result[i].Extension < result[j].Extension
+	})
+
+	return result
+}
+
+// HumanSize formats a size in bytes the way du -h does, with powers of 1024.
+func HumanSize(size int64) string {
+	const units = "KMGTPE"
+
+	if size < 1024 {
+		return fmt.Sprintf("%dB", size)
+	}
+
+	value := float64(size)
+	unit := -1
+	for value >= 1024 && unit < len(units)-1 {
+		value /= 1024
+		unit++
+	}
+
+	if value < 10 {
+		return fmt.Sprintf("%.1f%c", value, units[unit])
+	}
+
+	return fmt.Sprintf("%.0f%c", value, units[unit])
+}
+
+// RenderTree draws the tree like the tree command, with the size of every
+// file and dir.
+func RenderTree(root *vfs.Dir) string {
+	var sb strings.Builder
+
+	fmt.Fprintf(&sb, "%s (%s)\n", root.Path(), HumanSize(root.Size()))
+	renderChildren(&sb, newNode(root), "")
+
+	return sb.String()
+}
+
+func renderChildren(sb *strings.Builder, n *Node, prefix string) {
+	for i, child := range n.Children {
+		connector, childPrefix := "├── ", "│   "
+		if i == len(n.Children)-1 {
+			connector, childPrefix = "└── ", "    "
+		}
+
+		name := child.Name
+		if child.IsDir {
+			name += "/"
+		}
+
+		fmt.Fprintf(sb, "%s%s%s (%s)\n", prefix, connector, name, HumanSize(child.Size))
+
+		if child.IsDir {
+			renderChildren(sb, child, prefix+childPrefix)
+		}
+	}
+}
@@ -0,0 +1,561 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rarguelloF/advent-of-code-2022/vfs"
+)
+
+// Command runs a command with its arguments. If the command prints something,
+// it returns the func that handles every line of its output.
+type Command func(sh *Shell, args []string) (OutputFunc, error)
+
+type OutputFunc func(sh *Shell, line string) error
+
+const (
+	CommandChangeDir  = "cd"
+	CommandList       = "ls"
+	CommandPrintDir   = "pwd"
+	CommandMakeDir    = "mkdir"
+	CommandRemove     = "rm"
+	commandLinePrefix = "$ "
+)
+
+// Shell replays a terminal transcript on a filesystem. Dirs are created as
+// soon as they show up, either in a listing or as the target of a cd, so the
+// resulting tree does not depend on the order they were explored in.
+type Shell struct {
+	FS  *vfs.FS
+	Cwd *vfs.Dir
+	// Line is the number of the transcript line being run, starting at 1.
+	Line int
+	// Policy decides what to do when listings disagree, and Conflicts holds
+	// every disagreement found.
+	Policy    ConflictPolicy
+	Conflicts []*Conflict
+
+	commands  map[string]Command
+	output    OutputFunc
+	outputEnd func() error
+	// listings holds the entries of every listed dir, by path
+	listings map[string]map[string]listedEntry
+	// ignored holds the dirs left out of the tree by PolicyFirstWins, which
+	// are created in ghost instead so their output goes nowhere
+	ignored map[string]bool
+	ghost   *vfs.FS
+}
+
+// NewShell returns a shell at the root of an empty filesystem, with the
+// default commands registered.
+func NewShell() *Shell {
+	fsys := vfs.New()
+
+	sh := &Shell{
+		FS:       fsys,
+		Cwd:      fsys.Root(),
+		commands: make(map[string]Command, 0),
+		listings: make(map[string]map[string]listedEntry, 0),
+		ignored:  make(map[string]bool, 0),
+		ghost:    vfs.New(),
+	}
+
+	sh.Register(CommandChangeDir, changeDir)
+	sh.Register(CommandList, list)
+	sh.Register(CommandPrintDir, printDir)
+	sh.Register(CommandMakeDir, makeDir)
+	sh.Register(CommandRemove, remove)
+
+	return sh
+}
+
+// Register adds a command, replacing any other with the same name.
+func (sh *Shell) Register(name string, cmd Command) {
+	sh.commands[name] = cmd
+}
+
+// RunLine runs a transcript line, either a command or a line of the output of
+// the last one.
+func (sh *Shell) RunLine(line string) error {
+	sh.Line++
+
+	if err := sh.runLine(line); err != nil {
+		// conflicts already tell their line
+		var conflict *Conflict
+		if errors.As(err, &conflict) {
+			return err
+		}
+
+		return fmt.Errorf("line %d: %w", sh.Line, err)
+	}
+
+	return nil
+}
+
+// Close ends the output of the last command and sorts the conflicts by
+// line. It must be called after the last line.
+func (sh *Shell) Close() error {
+	err := sh.endOutput()
+
+	// missing entries are only found at the end of their listing
+	sort.SliceStable(sh.Conflicts, func(i, j int) bool {
+		return sh.Conflicts[i].Line < sh.Conflicts[j].Line
+	})
+
+	return err
+}
+
+// AtOutputEnd sets a func to run once the output of the current command ends.
+func (sh *Shell) AtOutputEnd(fn func() error) {
+	sh.outputEnd = fn
+}
+
+func (sh *Shell) endOutput() error {
+	fn := sh.outputEnd
+	sh.output, sh.outputEnd = nil, nil
+
+	if fn == nil {
+		return nil
+	}
+
+	return fn()
+}
+
+// conflict records a conflict, and returns it as an error if the policy says
+// so.
+func (sh *Shell) conflict(c *Conflict) error {
+	sh.Conflicts = append(sh.Conflicts, c)
+
+	if sh.Policy == PolicyError {
+		return c
+	}
+
+	return nil
+}
+
+func (sh *Shell) runLine(line string) error {
+	if len(line) == 0 {
+		return errors.New("found empty line")
+	}
+
+	if !strings.HasPrefix(line, commandLinePrefix) {
+		if sh.output == nil {
+			return fmt.Errorf("unexpected line: %s", line)
+		}
+
+		return sh.output(sh, line)
+	}
+
+	if err := sh.endOutput(); err != nil {
+		return err
+	}
+
+	fields := strings.Fields(line[len(commandLinePrefix):])
+	if len(fields) == 0 {
+		return errors.New("found empty command")
+	}
+
+	name, args := fields[0], fields[1:]
+
+	cmd, ok := sh.commands[name]
+	if !ok {
+		return fmt.Errorf("unknown command: %s", name)
+	}
+
+	output, err := cmd(sh, args)
+	if err != nil {
+		var conflict *Conflict
+		if errors.As(err, &conflict) {
+			return err
+		}
+
+		return fmt.Errorf("%s: %w", name, err)
+	}
+
+	sh.output = output
+	return nil
+}
+
+// Resolve returns the absolute path of p, which can be relative to the
+// current dir. Going above the root is an error.
+func (sh *Shell) Resolve(p string) (string, error) {
+	parts := make([]string, 0)
+	if !strings.HasPrefix(p, "/") {
+		parts = append(parts, strings.Split(strings.TrimPrefix(sh.Cwd.Path(), "/"), "/")...)
+	}
+
+	resolved := make([]string, 0, len(parts))
+	for _, part := range append(parts, strings.Split(p, "/")...) {
+		switch part {
+		case "", ".":
+			continue
+
+		case "..":
+			if len(resolved) == 0 {
+				return "", fmt.Errorf("cannot go above the root: %s", p)
+			}
+
+			resolved = resolved[:len(resolved)-1]
+
+		default:
+			resolved = append(resolved, part)
+		}
+	}
+
+	return "/" + path.Join(resolved...), nil
+}
+
+// isCwdOrParent tells whether the dir at the absolute path p is the current
+// dir or one of its parents.
+func (sh *Shell) isCwdOrParent(p string) bool {
+	cwd := sh.Cwd.Path()
+	return p == "/" || cwd == p || strings.HasPrefix(cwd, p+"/")
+}
+
+func changeDir(sh *Shell, args []string) (OutputFunc, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("expected 1 argument, got %d", len(args))
+	}
+
+	p, err := sh.Resolve(args[0])
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := sh.enterDir(p)
+	if err != nil {
+		return nil, err
+	}
+
+	sh.Cwd = dir
+	return nil, nil
+}
+
+// enterDir returns the dir at the absolute path p, creating it if it was not
+// listed yet. Dirs missing from the listing of their parent are conflicts.
+func (sh *Shell) enterDir(p string) (*vfs.Dir, error) {
+	cur := "/"
+	for _, name := range strings.Split(strings.TrimPrefix(p, "/"), "/") {
+		if name == "" {
+			break
+		}
+
+		child := path.Join(cur, name)
+		if sh.ignored[child] {
+			return sh.ghost.MkdirAll(p)
+		}
+
+		if entries, ok := sh.listings[cur]; ok {
+			if _, ok := entries[name]; !ok {
+				c := &Conflict{Kind: ConflictAdded, Line: sh.Line, Path: child, Detail: "dir not in its parent listing"}
+				if err := sh.conflict(c); err != nil {
+					return nil, err
+				}
+
+				if sh.Policy == PolicyFirstWins {
+					sh.ignored[child] = true
+					return sh.ghost.MkdirAll(p)
+				}
+			}
+		}
+
+		cur = child
+	}
+
+	dir, err := sh.FS.MkdirAll(p)
+	if err != nil {
+		return nil, err
+	}
+
+	sh.recordDirs(p)
+	return dir, nil
+}
+
+// fsAt returns the filesystem holding the absolute path p, which is the ghost
+// one for the dirs left out of the tree and everything below them.
+func (sh *Shell) fsAt(p string) *vfs.FS {
+	for cur := p; cur != "/"; cur = path.Dir(cur) {
+		if sh.ignored[cur] {
+			return sh.ghost
+		}
+	}
+
+	return sh.FS
+}
+
+func list(sh *Shell, args []string) (OutputFunc, error) {
+	if len(args) > 1 {
+		return nil, fmt.Errorf("expected at most 1 argument, got %d", len(args))
+	}
+
+	dir := sh.Cwd
+	if len(args) == 1 {
+		p, err := sh.Resolve(args[0])
+		if err != nil {
+			return nil, err
+		}
+
+		if dir, err = sh.enterDir(p); err != nil {
+			return nil, err
+		}
+	}
+
+	dirPath, lsLine := dir.Path(), sh.Line
+	prev, listedBefore := sh.listings[dirPath]
+	cur := make(map[string]listedEntry, 0)
+
+	sh.AtOutputEnd(func() error {
+		return sh.endListing(dir, lsLine, prev, cur)
+	})
+
+	return func(sh *Shell, line string) error {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return sh.conflict(&Conflict{Kind: ConflictMalformed, Line: sh.Line, Detail: fmt.Sprintf("expected 2 fields: %s", line)})
+		}
+
+		info, name := fields[0], fields[1]
+		entry := listedEntry{isDir: info == "dir", line: sh.Line}
+
+		if !entry.isDir {
+			size, err := strconv.ParseInt(info, 10, 64)
+			if err != nil || size < 0 {
+				return sh.conflict(&Conflict{Kind: ConflictMalformed, Line: sh.Line, Detail: fmt.Sprintf("expected a size as first field: %s", line)})
+			}
+
+			entry.size = size
+		}
+
+		// the entry is compared with the one in this same listing, if it is
+		// repeated, or else with the one in the last listing
+		known, ok := cur[name]
+		if !ok && listedBefore {
+			known, ok = prev[name]
+
+			if !ok {
+				c := &Conflict{Kind: ConflictAdded, Line: sh.Line, Path: path.Join(dirPath, name), Detail: entry.String()}
+				if err := sh.conflict(c); err != nil {
+					return err
+				}
+
+				if sh.Policy == PolicyFirstWins {
+					return nil
+				}
+			}
+		}
+
+		if ok && !known.sameAs(entry) {
+			kind := ConflictSizeChanged
+			if known.isDir != entry.isDir {
+				kind = ConflictTypeChanged
+			}
+
+			c := &Conflict{
+				Kind:     kind,
+				Line:     sh.Line,
+				PrevLine: known.line,
+				Path:     path.Join(dirPath, name),
+				Detail:   fmt.Sprintf("%s, was %s", entry, known),
+			}
+			if err := sh.conflict(c); err != nil {
+				return err
+			}
+
+			if sh.Policy == PolicyFirstWins {
+				cur[name] = known
+				return nil
+			}
+
+			if kind == ConflictTypeChanged {
+				if err := dir.Remove(name); err != nil {
+					return err
+				}
+			}
+		}
+
+		cur[name] = entry
+
+		if entry.isDir {
+			_, err := dir.AddDir(name)
+			return err
+		}
+
+		_, err := dir.AddFile(name, entry.size)
+		return err
+	}, nil
+}
+
+// endListing checks that a listing shows everything the last one of the same
+// dir did, and keeps it for the next one.
+func (sh *Shell) endListing(dir *vfs.Dir, lsLine int, prev, cur map[string]listedEntry) error {
+	if prev == nil {
+		sh.listings[dir.Path()] = cur
+		return nil
+	}
+
+	names := make([]string, 0)
+	for name := range prev {
+		if _, ok := cur[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		c := &Conflict{
+			Kind:     ConflictMissing,
+			Line:     lsLine,
+			PrevLine: prev[name].line,
+			Path:     path.Join(dir.Path(), name),
+			Detail:   prev[name].String(),
+		}
+		if err := sh.conflict(c); err != nil {
+			return err
+		}
+
+		if sh.Policy == PolicyLastWins {
+			// it might have been removed in another way already
+			_ = dir.Remove(name)
+		}
+	}
+
+	if sh.Policy == PolicyLastWins {
+		sh.listings[dir.Path()] = cur
+	}
+
+	return nil
+}
+
+// recordDirs adds the dir at the absolute path p, and its parents, to the
+// listings of their parents, so later listings can show them.
+func (sh *Shell) recordDirs(p string) {
+	for p != "/" {
+		parent, name := path.Split(p)
+		parent = vfs.Clean(parent)
+
+		if entries, ok := sh.listings[parent]; ok {
+			if _, ok := entries[name]; !ok {
+				entries[name] = listedEntry{isDir: true, line: sh.Line}
+			}
+		}
+
+		p = parent
+	}
+}
+
+// forget drops the removed path from the listings.
+func (sh *Shell) forget(p string) {
+	parent, name := path.Split(p)
+	if entries, ok := sh.listings[vfs.Clean(parent)]; ok {
+		delete(entries, name)
+	}
+
+	for listed := range sh.listings {
+		if listed == p || strings.HasPrefix(listed, p+"/") {
+			delete(sh.listings, listed)
+		}
+	}
+}
+
+func printDir(sh *Shell, args []string) (OutputFunc, error) {
+	if len(args) != 0 {
+		return nil, fmt.Errorf("expected no arguments, got %d", len(args))
+	}
+
+	return func(sh *Shell, line string) error {
+		if line != sh.Cwd.Path() {
+			return fmt.Errorf("printed dir %s but current dir is %s", line, sh.Cwd.Path())
+		}
+
+		return nil
+	}, nil
+}
+
+func makeDir(sh *Shell, args []string) (OutputFunc, error) {
+	parents := len(args) > 0 && args[0] == "-p"
+	if parents {
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		return nil, errors.New("missing operand")
+	}
+
+	for _, arg := range args {
+		p, err := sh.Resolve(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		fsys := sh.fsAt(p)
+		if parents {
+			_, err = fsys.MkdirAll(p)
+		} else {
+			_, err = fsys.Mkdir(p)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if fsys == sh.FS {
+			sh.recordDirs(p)
+		}
+	}
+
+	return nil, nil
+}
+
+func remove(sh *Shell, args []string) (OutputFunc, error) {
+	recursive := false
+	for len(args) > 0 && strings.HasPrefix(args[0], "-") {
+		switch args[0] {
+		case "-r", "-rf", "-fr", "-R":
+			recursive = true
+
+		case "-f":
+			// nothing to prompt for anyway
+
+		default:
+			return nil, fmt.Errorf("unknown option: %s", args[0])
+		}
+
+		args = args[1:]
+	}
+
+	if len(args) == 0 {
+		return nil, errors.New("missing operand")
+	}
+
+	for _, arg := range args {
+		p, err := sh.Resolve(arg)
+		if err != nil {
+			return nil, err
+		}
+
+		if sh.isCwdOrParent(p) {
+			return nil, fmt.Errorf("cannot remove the current dir or its parents: %s", p)
+		}
+
+		fsys := sh.fsAt(p)
+		if recursive {
+			err = fsys.RemoveAll(p)
+		} else if _, dirErr := fsys.LookupDir(p); dirErr == nil {
+			err = fmt.Errorf("cannot remove %s: is a directory", p)
+		} else {
+			err = fsys.Remove(p)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if fsys == sh.FS {
+			sh.forget(p)
+		}
+	}
+
+	return nil, nil
+}
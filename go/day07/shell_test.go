@@ -0,0 +1,102 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func runLines(sh *Shell, lines []string) error {
+	for _, line := range lines {
+		if err := sh.RunLine(line); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func exists(sh *Shell, p string) bool {
+	_, _, err := sh.FS.Lookup(p)
+	return err == nil
+}
+
+// TestUnlistedDirCommands goes into a dir missing from the listing of its
+// parent and then creates and removes dirs inside it, which under the first
+// wins policy must never reach the tree.
+func TestUnlistedDirCommands(t *testing.T) {
+	mkdirLines := []string{
+		"$ cd /",
+		"$ ls",
+		"dir a",
+		"10 x",
+		"$ cd b",
+		"$ mkdir -p q/r",
+		"$ ls",
+		"5 y",
+	}
+	rmLines := []string{
+		"$ rm -r q",
+		"$ cd /",
+	}
+
+	tests := []struct {
+		name   string
+		policy ConflictPolicy
+		// errLine is the line that fails, or 0 if none does
+		errLine int
+		// inTree tells whether /b and what is below it end up in the tree
+		inTree bool
+	}{
+		{name: "last", policy: PolicyLastWins, inTree: true},
+		{name: "first", policy: PolicyFirstWins, inTree: false},
+		{name: "error", policy: PolicyError, errLine: 5},
+	}
+
+	for _, tt := range tests {
+		sh := NewShell()
+		sh.Policy = tt.policy
+
+		err := runLines(sh, mkdirLines)
+		if tt.errLine > 0 {
+			var conflict *Conflict
+			if !errors.As(err, &conflict) || conflict.Line != tt.errLine || conflict.Kind != ConflictAdded {
+				t.Fatalf("%s: got error %v, want an added entry at line %d", tt.name, err, tt.errLine)
+			}
+
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		for _, p := range []string{"/b", "/b/q/r", "/b/y"} {
+			if got := exists(sh, p); got != tt.inTree {
+				t.Fatalf("%s: %s in the tree = %t, want %t", tt.name, p, got, tt.inTree)
+			}
+		}
+
+		if err := runLines(sh, rmLines); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if err := sh.Close(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		if exists(sh, "/b/q") {
+			t.Fatalf("%s: /b/q still in the tree after rm", tt.name)
+		}
+
+		if got := exists(sh, "/b/y"); got != tt.inTree {
+			t.Fatalf("%s: /b/y in the tree = %t, want %t", tt.name, got, tt.inTree)
+		}
+
+		if !exists(sh, "/a") || !exists(sh, "/x") {
+			t.Fatalf("%s: listed entries missing from the tree", tt.name)
+		}
+
+		if len(sh.Conflicts) != 1 || sh.Conflicts[0].Kind != ConflictAdded || sh.Conflicts[0].Path != "/b" {
+			t.Fatalf("%s: got conflicts %v, want an added /b", tt.name, sh.Conflicts)
+		}
+	}
+}
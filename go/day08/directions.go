@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Vector is a step in the grid, in rows and columns.
+type Vector struct {
+	Row int
+	Col int
+}
+
+func (v Vector) String() string {
+	return fmt.Sprintf("(%d,%d)", v.Row, v.Col)
+}
+
+type Direction int
+
+const (
+	DirectionTop Direction = iota
+	DirectionBottom
+	DirectionLeft
+	DirectionRight
+)
+
+func (d Direction) String() string {
+	switch d {
+	case DirectionTop:
+		return "top"
+
+	case DirectionBottom:
+		return "bottom"
+
+	case DirectionLeft:
+		return "left"
+
+	case DirectionRight:
+		return "right"
+
+	default:
+		return fmt.Sprintf("unknown (%d)", int(d))
+	}
+}
+
+func (d Direction) Vector() Vector {
+	switch d {
+	case DirectionTop:
+		return Vector{Row: -1}
+
+	case DirectionBottom:
+		return Vector{Row: 1}
+
+	case DirectionLeft:
+		return Vector{Col: -1}
+
+	case DirectionRight:
+		return Vector{Col: 1}
+
+	default:
+		return Vector{}
+	}
+}
+
+// DirectionSet holds the directions the trees look along.
+type DirectionSet []Vector
+
+// Label names a vector of the set by its Direction if it has one.
+func (set DirectionSet) Label(i int) string {
+	for d := DirectionTop; d <= DirectionRight; d++ {
+		if set[i] == d.Vector() {
+			return d.String()
+		}
+	}
+
+	return set[i].String()
+}
+
+var (
+	// CardinalDirections is in the same order as Direction, so the viewing
+	// distances can be indexed by it.
+	CardinalDirections = DirectionSet{
+		DirectionTop.Vector(),
+		DirectionBottom.Vector(),
+		DirectionLeft.Vector(),
+		DirectionRight.Vector(),
+	}
+	DiagonalDirections = DirectionSet{{-1, -1}, {-1, 1}, {1, -1}, {1, 1}}
+	KnightDirections   = DirectionSet{{-2, -1}, {-2, 1}, {-1, -2}, {-1, 2}, {1, -2}, {1, 2}, {2, -1}, {2, 1}}
+)
+
+var directionSets = map[string]DirectionSet{
+	"cardinal": CardinalDirections,
+	"diagonal": DiagonalDirections,
+	"all":      append(append(DirectionSet{}, CardinalDirections...), DiagonalDirections...),
+	"knight":   KnightDirections,
+}
+
+// ParseDirectionSet returns the set with the given name, or the sets joined
+// with + in it, like "cardinal+knight".
+func ParseDirectionSet(s string) (DirectionSet, error) {
+	set := make(DirectionSet, 0)
+	seen := make(map[Vector]bool, 0)
+
+	for _, name := range strings.Split(s, "+") {
+		named, ok := directionSets[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown direction set (expected one of %s): %s", strings.Join(directionSetNames(), ", "), name)
+		}
+
+		for _, v := range named {
+			if !seen[v] {
+				seen[v] = true
+				set = append(set, v)
+			}
+		}
+	}
+
+	return set, nil
+}
+
+func directionSetNames() []string {
+	names := make([]string, 0, len(directionSets))
+	for name := range directionSets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -4,100 +4,70 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/rarguelloF/advent-of-code-2022/input"
 )
 
-const inputName = "day08"
-
-type Direction int
-
 const (
-	DirectionTop Direction = iota
-	DirectionBottom
-	DirectionLeft
-	DirectionRight
+	inputName = "day08"
+	// defaultPNGScale is the size in pixels of every tree in the images
+	defaultPNGScale = 4
 )
 
 type Trees [][]int
 
-func (t Trees) IsVisible(row, col int) bool {
-	// outer trees are always visible
-	if t.isOuterTree(row, col) {
-		return true
+func (t Trees) inside(row, col int) bool {
+	return row >= 0 && row < len(t) && col >= 0 && col < len(t[row])
+}
+
+// IsVisible tells whether the tree can be seen from outside the forest along
+// any of the directions.
+func (t Trees) IsVisible(row, col int, set DirectionSet) bool {
+	for _, v := range set {
+		if _, ok := t.countVisibleTrees(row, col, v); ok {
+			return true
+		}
 	}
 
-	return t.isVisibleFromBottom(row, col) ||
-		t.isVisibleFromTop(row, col) ||
-		t.isVisibleFromLeft(row, col) ||
-		t.isVisibleFromRight(row, col)
+	return false
 }
 
-func (t Trees) VisibilityScore(row, col int) int {
-	treesBottom, _ := t.countVisibleTrees(row, col, DirectionBottom)
-	treesTop, _ := t.countVisibleTrees(row, col, DirectionTop)
-	treesLeft, _ := t.countVisibleTrees(row, col, DirectionLeft)
-	treesRight, _ := t.countVisibleTrees(row, col, DirectionRight)
+// VisibilityScore multiplies the viewing distances, saturating at MaxScore.
+func (t Trees) VisibilityScore(row, col int, set DirectionSet) int {
+	distances := make([]int, 0, len(set))
+	for _, v := range set {
+		numTrees, _ := t.countVisibleTrees(row, col, v)
+		distances = append(distances, numTrees)
+	}
 
-	return treesBottom * treesTop * treesLeft * treesRight
+	return scoreOf(distances)
 }
 
-func (t Trees) countVisibleTrees(row, col int, direction Direction) (numTrees int, reachedEnd bool) {
-	if t.isOuterTree(row, col) {
-		return 0, true
+// formatScore marks the saturated scores, since they are not exact.
+func formatScore(score int) string {
+	if score == MaxScore {
+		return fmt.Sprintf(">=%d (overflow)", score)
 	}
 
-	height := t[row][col]
-	numRows, numCols := len(t), len(t[0])
-
-	var loopRows bool
-	var loopStart, loopEnd, loopIncrease int
-
-	switch direction {
-	case DirectionTop:
-		loopRows = true
-		loopStart = row - 1
-		loopEnd = 0
-		loopIncrease = -1
-
-	case DirectionBottom:
-		loopRows = true
-		loopStart = row + 1
-		loopEnd = numRows - 1
-		loopIncrease = 1
-
-	case DirectionLeft:
-		loopStart = col - 1
-		loopEnd = 0
-		loopIncrease = -1
-
-	case DirectionRight:
-		loopStart = col + 1
-		loopEnd = numCols - 1
-		loopIncrease = 1
-
-	default:
+	return strconv.Itoa(score)
+}
+
+// countVisibleTrees walks from the tree along the vector until a tree at
+// least as tall is found, or the edge of the forest is reached.
+func (t Trees) countVisibleTrees(row, col int, v Vector) (numTrees int, reachedEnd bool) {
+	if v == (Vector{}) {
 		return 0, false
 	}
 
-	checkLoop := func(i int) bool {
-		if loopIncrease < 0 {
-			return i >= loopEnd
-		}
-		return i <= loopEnd
-	}
+	height := t[row][col]
 
-	for i := loopStart; checkLoop(i); i += loopIncrease {
+	for r, c := row+v.Row, col+v.Col; t.inside(r, c); r, c = r+v.Row, c+v.Col {
 		numTrees++
-		r, c := row, col
-		if loopRows {
-			r = i
-		} else {
-			c = i
-		}
 
-		otherHeight := t[r][c]
-		if otherHeight >= height {
+		if t[r][c] >= height {
 			return numTrees, false
 		}
 	}
@@ -105,37 +75,12 @@ func (t Trees) countVisibleTrees(row, col int, direction Direction) (numTrees in
 	return numTrees, true
 }
 
-func (t Trees) isVisibleFromBottom(row, col int) bool {
-	_, ok := t.countVisibleTrees(row, col, DirectionBottom)
-	return ok
-}
-
-func (t Trees) isVisibleFromTop(row, col int) bool {
-	_, ok := t.countVisibleTrees(row, col, DirectionTop)
-	return ok
-}
-
-func (t Trees) isVisibleFromLeft(row, col int) bool {
-	_, ok := t.countVisibleTrees(row, col, DirectionLeft)
-	return ok
-}
-
-func (t Trees) isVisibleFromRight(row, col int) bool {
-	_, ok := t.countVisibleTrees(row, col, DirectionRight)
-	return ok
-}
-
-func (t Trees) isOuterTree(row, col int) bool {
-	numCols := len(t[0])
-	return row <= 0 || row >= len(t)-1 || col == 0 || col >= numCols-1
-}
-
-func PartOne(trees Trees) {
+func PartOne(sight *Sight) {
 	totalVisibleTrees := 0
 
-	for row := 0; row < len(trees); row++ {
-		for col := 0; col < len(trees[row]); col++ {
-			if trees.IsVisible(row, col) {
+	for row := range sight.Visible {
+		for col := range sight.Visible[row] {
+			if sight.Visible[row][col] {
 				totalVisibleTrees++
 			}
 		}
@@ -144,41 +89,41 @@ func PartOne(trees Trees) {
 	fmt.Printf("Part 1: %d\n", totalVisibleTrees)
 }
 
-func PartTwo(trees Trees) {
+func PartTwo(sight *Sight) {
 	maxVisibility := 0
 
-	for row := 0; row < len(trees); row++ {
-		for col := 0; col < len(trees[row]); col++ {
-			s := trees.VisibilityScore(row, col)
+	for row := range sight.Distances {
+		for col := range sight.Distances[row] {
+			s := sight.Score(row, col)
 			if s > maxVisibility {
 				maxVisibility = s
 			}
 		}
 	}
 
-	fmt.Printf("Part 2: %d\n", maxVisibility)
+	fmt.Printf("Part 2: %s\n", formatScore(maxVisibility))
 }
 
 func readInput() (Trees, error) {
+	format := FormatAuto
+	if formatStr, ok := input.FlagValue("format"); ok {
+		var err error
+		if format, err = ParseHeightFormat(formatStr); err != nil {
+			return nil, err
+		}
+	}
+
 	trees := make(Trees, 0)
-	numCols := -1
+	parser := NewHeightParser(format)
 
 	processLine := func(line string) error {
 		if len(line) == 0 {
 			return errors.New("found empty line")
 		}
 
-		cols := len(line)
-		if numCols == -1 {
-			numCols = cols
-		} else if cols != numCols {
-			return errors.New("number of columns should be the same for every row")
-		}
-
-		treeRow := make([]int, 0, cols)
-		for _, h := range line {
-			height := int(h - '0')
-			treeRow = append(treeRow, height)
+		treeRow, err := parser.ParseLine(line)
+		if err != nil {
+			return err
 		}
 
 		trees = append(trees, treeRow)
@@ -192,12 +137,140 @@ func readInput() (Trees, error) {
 	return trees, nil
 }
 
+func readLocationFilter() (*LocationFilter, error) {
+	filter := &LocationFilter{MustBeVisible: input.HasFlag("visible")}
+
+	if minHeightStr, ok := input.FlagValue("min-height"); ok {
+		n, err := strconv.Atoi(minHeightStr)
+		if err != nil {
+			return nil, fmt.Errorf("min height is not a number: %s", minHeightStr)
+		}
+
+		filter.MinHeight = n
+	}
+
+	if regionStr, ok := input.FlagValue("region"); ok {
+		region, err := ParseRegion(regionStr)
+		if err != nil {
+			return nil, err
+		}
+
+		filter.Region = region
+	}
+
+	return filter, nil
+}
+
+// showTopLocations prints the best locations when -top is given.
+func showTopLocations(trees Trees, sight *Sight) error {
+	topStr, ok := input.FlagValue("top")
+	if !ok {
+		return nil
+	}
+
+	k, err := strconv.Atoi(topStr)
+	if err != nil {
+		return fmt.Errorf("top is not a number: %s", topStr)
+	}
+
+	filter, err := readLocationFilter()
+	if err != nil {
+		return err
+	}
+
+	for i, l := range TopLocations(trees, sight, k, filter) {
+		distances := make([]string, len(l.Distances))
+		for j, d := range l.Distances {
+			distances[j] = fmt.Sprintf("%s=%d", sight.Directions.Label(j), d)
+		}
+
+		fmt.Printf(
+			"%d. row %d, col %d, height %d, score %s, visible %t, %s\n",
+			i+1, l.Row, l.Col, l.Height, formatScore(l.Score), l.Visible, strings.Join(distances, " "),
+		)
+	}
+
+	return nil
+}
+
+// renderHeatmaps prints the heatmap chosen with -heatmap and writes it as
+// an image to the path in -png, with the scores heatmap by default.
+func renderHeatmaps(trees Trees, sight *Sight) error {
+	kindStr, showHeatmap := input.FlagValue("heatmap")
+	pngPath, writePNG := input.FlagValue("png")
+	if !showHeatmap && !writePNG {
+		return nil
+	}
+
+	kind := HeatmapScores
+	if showHeatmap {
+		var err error
+		if kind, err = ParseHeatmapKind(kindStr); err != nil {
+			return err
+		}
+	}
+
+	values := Heatmap(trees, sight, kind)
+	bestRow, bestCol, bestScore := sight.Best()
+
+	if showHeatmap {
+		fmt.Print(RenderANSI(values, bestRow, bestCol))
+		fmt.Printf("Best location: row %d, col %d, score %s\n", bestRow, bestCol, formatScore(bestScore))
+	}
+
+	if !writePNG {
+		return nil
+	}
+
+	scale := defaultPNGScale
+	if scaleStr, ok := input.FlagValue("scale"); ok {
+		n, err := strconv.Atoi(scaleStr)
+		if err != nil {
+			return fmt.Errorf("scale is not a number: %s", scaleStr)
+		}
+
+		scale = n
+	}
+
+	f, err := os.Create(pngPath)
+	if err != nil {
+		return fmt.Errorf("failed to create image: %w", err)
+	}
+
+	if err := WritePNG(f, values, bestRow, bestCol, scale); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write image: %w", err)
+	}
+
+	return f.Close()
+}
+
 func main() {
 	trees, err := readInput()
 	if err != nil {
 		log.Fatal(err)
 	}
 
-	PartOne(trees)
-	PartTwo(trees)
+	set := CardinalDirections
+	if name, ok := input.FlagValue("directions"); ok {
+		if set, err = ParseDirectionSet(name); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	sight, err := trees.Sight(set)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	PartOne(sight)
+	PartTwo(sight)
+
+	if err := showTopLocations(trees, sight); err != nil {
+		log.Fatal(err)
+	}
+
+	if err := renderHeatmaps(trees, sight); err != nil {
+		log.Fatal(err)
+	}
 }
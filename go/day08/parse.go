@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type HeightFormat int
+
+const (
+	// FormatAuto chooses the format from the first line.
+	FormatAuto HeightFormat = iota
+	// FormatDigits has a single digit for every tree, like 30373.
+	FormatDigits
+	// FormatSeparated has numbers of any length separated by whitespace,
+	// like 3 0 12 7.
+	FormatSeparated
+	// FormatLetters has a letter for every tree, from a for 0 to z for 25.
+	FormatLetters
+)
+
+func (f HeightFormat) String() string {
+	switch f {
+	case FormatAuto:
+		return "auto"
+
+	case FormatDigits:
+		return "digits"
+
+	case FormatSeparated:
+		return "separated"
+
+	case FormatLetters:
+		return "letters"
+
+	default:
+		return fmt.Sprintf("unknown (%d)", int(f))
+	}
+}
+
+func ParseHeightFormat(s string) (HeightFormat, error) {
+	for _, f := range []HeightFormat{FormatAuto, FormatDigits, FormatSeparated, FormatLetters} {
+		if s == f.String() {
+			return f, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown height format (expected auto, digits, separated or letters): %s", s)
+}
+
+// SniffHeightFormat guesses the format of a line: separated if it has any
+// whitespace inside, letters if all of it are letters and digits otherwise.
+func SniffHeightFormat(line string) HeightFormat {
+	line = strings.TrimSpace(line)
+
+	if strings.IndexFunc(line, unicode.IsSpace) != -1 {
+		return FormatSeparated
+	}
+
+	if line != "" && strings.IndexFunc(line, func(r rune) bool { return r < 'a' || r > 'z' }) == -1 {
+		return FormatLetters
+	}
+
+	return FormatDigits
+}
+
+var (
+	ErrInvalidHeight = errors.New("invalid height")
+	ErrRowLength     = errors.New("row has a different number of trees")
+)
+
+type HeightError struct {
+	// Line and Column are where the problem was found, starting at 1.
+	Line   int
+	Column int
+	Err    error
+}
+
+func (e *HeightError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %v", e.Line, e.Column, e.Err)
+}
+
+func (e *HeightError) Unwrap() error {
+	return e.Err
+}
+
+// HeightParser reads the rows of a height map one line at a time, checking
+// that all of them have the same number of trees.
+type HeightParser struct {
+	Format HeightFormat
+
+	line    int
+	numCols int
+}
+
+func NewHeightParser(format HeightFormat) *HeightParser {
+	return &HeightParser{Format: format, numCols: -1}
+}
+
+func (p *HeightParser) ParseLine(line string) ([]int, error) {
+	p.line++
+
+	if p.Format == FormatAuto {
+		p.Format = SniffHeightFormat(line)
+	}
+
+	var row []int
+	var err error
+
+	switch p.Format {
+	case FormatDigits:
+		row, err = p.parseRunes(line, '0', '9')
+
+	case FormatLetters:
+		row, err = p.parseRunes(line, 'a', 'z')
+
+	case FormatSeparated:
+		row, err = p.parseSeparated(line)
+
+	default:
+		return nil, fmt.Errorf("unknown height format: %s", p.Format)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(row) == 0 {
+		return nil, &HeightError{Line: p.line, Column: 1, Err: errors.New("row has no trees")}
+	}
+
+	if p.numCols == -1 {
+		p.numCols = len(row)
+	} else if len(row) != p.numCols {
+		return nil, &HeightError{
+			Line:   p.line,
+			Column: 1,
+			Err:    fmt.Errorf("%w: expected %d, got %d", ErrRowLength, p.numCols, len(row)),
+		}
+	}
+
+	return row, nil
+}
+
+// parseRunes reads a height for every rune, from 0 for zero onwards.
+func (p *HeightParser) parseRunes(line string, zero, max rune) ([]int, error) {
+	row := make([]int, 0, len(line))
+
+	for i, r := range []rune(line) {
+		if r < zero || r > max {
+			return nil, &HeightError{Line: p.line, Column: i + 1, Err: fmt.Errorf("%w: %q", ErrInvalidHeight, r)}
+		}
+
+		row = append(row, int(r-zero))
+	}
+
+	return row, nil
+}
+
+func (p *HeightParser) parseSeparated(line string) ([]int, error) {
+	row := make([]int, 0)
+	runes := []rune(line)
+
+	for i := 0; i < len(runes); {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) {
+			i++
+		}
+
+		field := string(runes[start:i])
+		height, err := strconv.Atoi(field)
+		if err != nil || height < 0 {
+			return nil, &HeightError{Line: p.line, Column: start + 1, Err: fmt.Errorf("%w: %s", ErrInvalidHeight, field)}
+		}
+
+		row = append(row, height)
+	}
+
+	return row, nil
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Location is a possible spot for the tree house. Rows and columns start at 0.
+type Location struct {
+	Row     int
+	Col     int
+	Height  int
+	Score   int
+	Visible bool
+	// Distances are the viewing distances, indexed like the directions of the
+	// sight they come from.
+	Distances []int
+}
+
+// better tells whether l ranks before other: higher scores first, and then
+// in reading order.
+func (l *Location) better(other *Location) bool {
+	if l.Score != other.Score {
+		return l.Score > other.Score
+	}
+
+	if l.Row != other.Row {
+		return l.Row < other.Row
+	}
+
+	return l.Col < other.Col
+}
+
+// Region is a rectangle of the grid, with both corners included.
+type Region struct {
+	MinRow int
+	MinCol int
+	MaxRow int
+	MaxCol int
+}
+
+// ParseRegion reads a region like "10,20:30,40", from row 10 and column 20
+// to row 30 and column 40.
+func ParseRegion(s string) (*Region, error) {
+	from, to, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, fmt.Errorf("expected a region like row,col:row,col: %s", s)
+	}
+
+	var corners [4]int
+	for i, corner := range []string{from, to} {
+		rowStr, colStr, ok := strings.Cut(corner, ",")
+		if !ok {
+			return nil, fmt.Errorf("expected a corner like row,col: %s", corner)
+		}
+
+		for j, str := range []string{rowStr, colStr} {
+			n, err := strconv.Atoi(strings.TrimSpace(str))
+			if err != nil {
+				return nil, fmt.Errorf("expected a number in region: %s", str)
+			}
+
+			corners[2*i+j] = n
+		}
+	}
+
+	r := &Region{MinRow: corners[0], MinCol: corners[1], MaxRow: corners[2], MaxCol: corners[3]}
+	if r.MinRow > r.MaxRow || r.MinCol > r.MaxCol {
+		return nil, fmt.Errorf("region corners are the wrong way around: %s", s)
+	}
+
+	return r, nil
+}
+
+func (r *Region) Contains(row, col int) bool {
+	return row >= r.MinRow && row <= r.MaxRow && col >= r.MinCol && col <= r.MaxCol
+}
+
+// LocationFilter leaves out the locations that do not match, its zero value
+// accepts all of them.
+type LocationFilter struct {
+	MinHeight     int
+	MustBeVisible bool
+	// Region is nil for the whole grid.
+	Region *Region
+}
+
+func (f *LocationFilter) accepts(trees Trees, sight *Sight, row, col int) bool {
+	if trees[row][col] < f.MinHeight {
+		return false
+	}
+
+	if f.MustBeVisible && !sight.Visible[row][col] {
+		return false
+	}
+
+	return f.Region == nil || f.Region.Contains(row, col)
+}
+
+// locationHeap keeps the worst location at the top, so it can be dropped
+// when a better one comes.
+type locationHeap []*Location
+
+func (h locationHeap) Len() int           { return len(h) }
+func (h locationHeap) Less(i, j int) bool { return h[j].better(h[i]) }
+func (h locationHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *locationHeap) Push(x any) {
+	*h = append(*h, x.(*Location))
+}
+
+func (h *locationHeap) Pop() any {
+	old := *h
+	l := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return l
+}
+
+// TopLocations returns the k best locations that pass the filter, ranked by
+// score and then in reading order. It only keeps k locations at a time.
+func TopLocations(trees Trees, sight *Sight, k int, filter *LocationFilter) []*Location {
+	if k <= 0 {
+		return []*Location{}
+	}
+
+	h := make(locationHeap, 0, k)
+
+	for row := range trees {
+		for col := range trees[row] {
+			if !filter.accepts(trees, sight, row, col) {
+				continue
+			}
+
+			l := &Location{Row: row, Col: col, Score: sight.Score(row, col)}
+			if len(h) == k {
+				if !l.better(h[0]) {
+					continue
+				}
+
+				heap.Pop(&h)
+			}
+
+			l.Height = trees[row][col]
+			l.Visible = sight.Visible[row][col]
+			l.Distances = sight.Distances[row][col]
+			heap.Push(&h, l)
+		}
+	}
+
+	locations := []*Location(h)
+	sort.Slice(locations, func(i, j int) bool {
+		return locations[i].better(locations[j])
+	})
+
+	return locations
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"strings"
+)
+
+type HeatmapKind int
+
+const (
+	HeatmapHeights HeatmapKind = iota
+	HeatmapVisibility
+	HeatmapScores
+)
+
+func ParseHeatmapKind(s string) (HeatmapKind, error) {
+	switch s {
+	case "heights":
+		return HeatmapHeights, nil
+
+	case "visibility":
+		return HeatmapVisibility, nil
+
+	case "scores":
+		return HeatmapScores, nil
+
+	default:
+		return 0, fmt.Errorf("unknown heatmap (expected heights, visibility or scores): %s", s)
+	}
+}
+
+// Best returns the location with the highest scenic score, the first one in
+// reading order if there are several.
+func (s *Sight) Best() (row, col, score int) {
+	score = -1
+	for r := range s.Distances {
+		for c := range s.Distances[r] {
+			if sc := s.Score(r, c); sc > score {
+				row, col, score = r, c, sc
+			}
+		}
+	}
+
+	return row, col, score
+}
+
+// Heatmap returns a value between 0 and 1 for every tree. Scores grow too
+// fast to compare them directly, so they go in a logarithmic scale.
+func Heatmap(trees Trees, sight *Sight, kind HeatmapKind) [][]float64 {
+	raw := make([][]float64, len(trees))
+	maxValue := 0.0
+
+	for row := range trees {
+		raw[row] = make([]float64, len(trees[row]))
+
+		for col := range trees[row] {
+			var v float64
+			switch kind {
+			case HeatmapHeights:
+				v = float64(trees[row][col])
+
+			case HeatmapVisibility:
+				if sight.Visible[row][col] {
+					v = 1
+				}
+
+			case HeatmapScores:
+				v = math.Log1p(float64(sight.Score(row, col)))
+			}
+
+			raw[row][col] = v
+			maxValue = math.Max(maxValue, v)
+		}
+	}
+
+	if maxValue > 0 {
+		for row := range raw {
+			for col := range raw[row] {
+				raw[row][col] /= maxValue
+			}
+		}
+	}
+
+	return raw
+}
+
+// heatColor goes from dark blue for 0 through green and yellow to red for 1.
+func heatColor(v float64) color.RGBA {
+	stops := []color.RGBA{
+		{R: 0x10, G: 0x20, B: 0x60, A: 0xff},
+		{R: 0x20, G: 0xa0, B: 0x60, A: 0xff},
+		{R: 0xf0, G: 0xe0, B: 0x30, A: 0xff},
+		{R: 0xd0, G: 0x20, B: 0x20, A: 0xff},
+	}
+
+	v = math.Max(0, math.Min(1, v)) * float64(len(stops)-1)
+	i := int(v)
+	if i == len(stops)-1 {
+		return stops[i]
+	}
+
+	from, to, frac := stops[i], stops[i+1], v-float64(i)
+	mix := func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*frac)
+	}
+
+	return color.RGBA{R: mix(from.R, to.R), G: mix(from.G, to.G), B: mix(from.B, to.B), A: 0xff}
+}
+
+// RenderANSI draws the heatmap with two coloured spaces for every tree, and
+// marks the best location with a white X.
+func RenderANSI(values [][]float64, bestRow, bestCol int) string {
+	var sb strings.Builder
+
+	for row := range values {
+		for col, v := range values[row] {
+			c := heatColor(v)
+			fmt.Fprintf(&sb, "\x1b[48;2;%d;%d;%dm", c.R, c.G, c.B)
+
+			if row == bestRow && col == bestCol {
+				sb.WriteString("\x1b[1;97mX \x1b[22;39m")
+			} else {
+				sb.WriteString("  ")
+			}
+		}
+
+		sb.WriteString("\x1b[0m\n")
+	}
+
+	return sb.String()
+}
+
+// WritePNG draws the heatmap with scale×scale pixels for every tree, and
+// marks the best location with a small white cross.
+func WritePNG(w io.Writer, values [][]float64, bestRow, bestCol, scale int) error {
+	if scale < 1 {
+		return fmt.Errorf("invalid scale: %d", scale)
+	}
+
+	numRows, numCols := len(values), 0
+	if numRows > 0 {
+		numCols = len(values[0])
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, numCols*scale, numRows*scale))
+
+	for row := range values {
+		for col, v := range values[row] {
+			c := heatColor(v)
+			for y := row * scale; y < (row+1)*scale; y++ {
+				for x := col * scale; x < (col+1)*scale; x++ {
+					img.SetRGBA(x, y, c)
+				}
+			}
+		}
+	}
+
+	if numRows > 0 && numCols > 0 {
+		white := color.RGBA{R: 0xff, G: 0xff, B: 0xff, A: 0xff}
+		centerX, centerY := bestCol*scale+scale/2, bestRow*scale+scale/2
+		arm := 2*scale + 1
+
+		for d := -arm; d <= arm; d++ {
+			if x := centerX + d; x >= 0 && x < numCols*scale {
+				img.SetRGBA(x, centerY, white)
+			}
+
+			if y := centerY + d; y >= 0 && y < numRows*scale {
+				img.SetRGBA(centerX, y, white)
+			}
+		}
+	}
+
+	return png.Encode(w, img)
+}
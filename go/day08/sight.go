@@ -0,0 +1,118 @@
+package main
+
+import (
+	"errors"
+	"math"
+)
+
+// MaxScore is where scores saturate. With many directions the product of the
+// viewing distances easily goes beyond what an int holds.
+const MaxScore = math.MaxInt
+
+// Sight holds, for every tree, whether it can be seen from outside the forest
+// and how far it can see along each direction of a set.
+type Sight struct {
+	Directions DirectionSet
+	Visible    [][]bool
+	// Distances holds the viewing distance of every tree, indexed like
+	// Directions. With CardinalDirections it can be indexed by Direction.
+	Distances [][][]int
+}
+
+// Score is the scenic score of the tree, which is 0 for the ones on the edge
+// along any of the directions, and MaxScore if it does not fit.
+func (s *Sight) Score(row, col int) int {
+	return scoreOf(s.Distances[row][col])
+}
+
+// scoreOf multiplies the viewing distances, saturating at MaxScore.
+func scoreOf(distances []int) int {
+	for _, d := range distances {
+		if d == 0 {
+			return 0
+		}
+	}
+
+	score := 1
+	for _, d := range distances {
+		if score > MaxScore/d {
+			return MaxScore
+		}
+
+		score *= d
+	}
+
+	return score
+}
+
+// Sight computes the visibility of every tree with a sweep of every sight
+// line in each direction. Visibility only needs the tallest tree seen so far,
+// and the viewing distance the trees that are not hidden behind a taller one,
+// kept in a stack with decreasing heights. So it takes O(rows×cols) for each
+// direction, instead of walking outwards from every tree.
+func (t Trees) Sight(set DirectionSet) (*Sight, error) {
+	s := &Sight{
+		Directions: set,
+		Visible:    make([][]bool, len(t)),
+		Distances:  make([][][]int, len(t)),
+	}
+	for row := range t {
+		s.Visible[row] = make([]bool, len(t[row]))
+		s.Distances[row] = make([][]int, len(t[row]))
+		for col := range t[row] {
+			s.Distances[row][col] = make([]int, len(set))
+		}
+	}
+
+	stack := make([]int, 0)
+
+	for k, v := range set {
+		if v == (Vector{}) {
+			return nil, errors.New("direction vectors cannot be zero")
+		}
+
+		// every sight line is swept starting from its tree nearest to the
+		// edge the vector points to, so the trees already seen are the ones
+		// in that direction
+		for row := range t {
+			for col := range t[row] {
+				if t.inside(row+v.Row, col+v.Col) {
+					continue
+				}
+
+				tallest := -1
+				stack = stack[:0]
+
+				for i, r, c := 0, row, col; t.inside(r, c); i, r, c = i+1, r-v.Row, c-v.Col {
+					height := t[r][c]
+
+					if height > tallest {
+						s.Visible[r][c] = true
+						tallest = height
+					}
+
+					// the trees lower than this one can never stop the view
+					// of the ones after it
+					for len(stack) > 0 {
+						j := stack[len(stack)-1]
+						if t[row-j*v.Row][col-j*v.Col] >= height {
+							break
+						}
+
+						stack = stack[:len(stack)-1]
+					}
+
+					if len(stack) > 0 {
+						s.Distances[r][c][k] = i - stack[len(stack)-1]
+					} else {
+						s.Distances[r][c][k] = i
+					}
+
+					stack = append(stack, i)
+				}
+			}
+		}
+	}
+
+	return s, nil
+}
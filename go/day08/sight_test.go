@@ -0,0 +1,240 @@
+package main
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func randomTrees(r *rand.Rand, numRows, numCols, maxHeight int) Trees {
+	trees := make(Trees, numRows)
+	for row := range trees {
+		trees[row] = make([]int, numCols)
+		for col := range trees[row] {
+			trees[row][col] = r.Intn(maxHeight + 1)
+		}
+	}
+
+	return trees
+}
+
+// baselineCountVisibleTrees is the walk along a single direction the puzzle
+// was first solved with, kept as it was to check the sweeps against it.
+func baselineCountVisibleTrees(t Trees, row, col int, direction Direction) (numTrees int, reachedEnd bool) {
+	if baselineIsOuterTree(t, row, col) {
+		return 0, true
+	}
+
+	height := t[row][col]
+	numRows, numCols := len(t), len(t[0])
+
+	var loopRows bool
+	var loopStart, loopEnd, loopIncrease int
+
+	switch direction {
+	case DirectionTop:
+		loopRows = true
+		loopStart = row - 1
+		loopEnd = 0
+		loopIncrease = -1
+
+	case DirectionBottom:
+		loopRows = true
+		loopStart = row + 1
+		loopEnd = numRows - 1
+		loopIncrease = 1
+
+	case DirectionLeft:
+		loopStart = col - 1
+		loopEnd = 0
+		loopIncrease = -1
+
+	case DirectionRight:
+		loopStart = col + 1
+		loopEnd = numCols - 1
+		loopIncrease = 1
+
+	default:
+		return 0, false
+	}
+
+	checkLoop := func(i int) bool {
+		if loopIncrease < 0 {
+			return i >= loopEnd
+		}
+		return i <= loopEnd
+	}
+
+	for i := loopStart; checkLoop(i); i += loopIncrease {
+		numTrees++
+		r, c := row, col
+		if loopRows {
+			r = i
+		} else {
+			c = i
+		}
+
+		otherHeight := t[r][c]
+		if otherHeight >= height {
+			return numTrees, false
+		}
+	}
+
+	return numTrees, true
+}
+
+func baselineIsOuterTree(t Trees, row, col int) bool {
+	numCols := len(t[0])
+	return row <= 0 || row >= len(t)-1 || col == 0 || col >= numCols-1
+}
+
+func baselineIsVisible(t Trees, row, col int) bool {
+	if baselineIsOuterTree(t, row, col) {
+		return true
+	}
+
+	for d := DirectionTop; d <= DirectionRight; d++ {
+		if _, ok := baselineCountVisibleTrees(t, row, col, d); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+func baselineVisibilityScore(t Trees, row, col int) int {
+	score := 1
+	for d := DirectionTop; d <= DirectionRight; d++ {
+		n, _ := baselineCountVisibleTrees(t, row, col, d)
+		score *= n
+	}
+
+	return score
+}
+
+// TestSightMatchesBaseline checks the sweeps along the cardinal directions
+// against the original walk, on random grids of every shape.
+func TestSightMatchesBaseline(t *testing.T) {
+	r := rand.New(rand.NewSource(2022))
+
+	for i := 0; i < 2000; i++ {
+		trees := randomTrees(r, 1+r.Intn(12), 1+r.Intn(12), r.Intn(10))
+
+		sight, err := trees.Sight(CardinalDirections)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for row := range trees {
+			for col := range trees[row] {
+				if got, want := sight.Visible[row][col], baselineIsVisible(trees, row, col); got != want {
+					t.Fatalf("visible at %d,%d = %t, want %t in %v", row, col, got, want, trees)
+				}
+
+				if got, want := sight.Score(row, col), baselineVisibilityScore(trees, row, col); got != want {
+					t.Fatalf("score at %d,%d = %d, want %d in %v", row, col, got, want, trees)
+				}
+			}
+		}
+	}
+}
+
+// TestSightMatchesWalk checks the sweeps against walking outwards from every
+// tree, on random grids of every shape and for every direction set.
+func TestSightMatchesWalk(t *testing.T) {
+	r := rand.New(rand.NewSource(2022))
+
+	for _, name := range directionSetNames() {
+		set, err := ParseDirectionSet(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 500; i++ {
+			// few heights give a lot of trees of the same height
+			trees := randomTrees(r, 1+r.Intn(12), 1+r.Intn(12), r.Intn(10))
+
+			sight, err := trees.Sight(set)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for row := range trees {
+				for col := range trees[row] {
+					if got, want := sight.Visible[row][col], trees.IsVisible(row, col, set); got != want {
+						t.Fatalf("%s: visible at %d,%d = %t, want %t in %v", name, row, col, got, want, trees)
+					}
+
+					if got, want := sight.Score(row, col), trees.VisibilityScore(row, col, set); got != want {
+						t.Fatalf("%s: score at %d,%d = %d, want %d in %v", name, row, col, got, want, trees)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestSightRejectsZeroVector(t *testing.T) {
+	trees := Trees{{1, 2}, {3, 4}}
+	if _, err := trees.Sight(DirectionSet{{Row: 1}, {}}); err == nil {
+		t.Fatal("expected an error for a zero vector")
+	}
+}
+
+func TestScoreSaturates(t *testing.T) {
+	tests := []struct {
+		distances []int
+		want      int
+	}{
+		{[]int{2, 3, 4}, 24},
+		{[]int{1 << 40, 1 << 30}, MaxScore},
+		// a zero anywhere wins over the overflow
+		{[]int{1 << 40, 1 << 30, 0}, 0},
+		{[]int{}, 1},
+	}
+
+	for _, tt := range tests {
+		if got := scoreOf(tt.distances); got != tt.want {
+			t.Errorf("scoreOf(%v) = %d, want %d", tt.distances, got, tt.want)
+		}
+	}
+}
+
+// TestScoreSaturatesOnLargeGrid looks along the cardinal directions a
+// thousand times over from the middle of a flat grid, which overflows any
+// int, and checks that every score stays within range and matches the walk.
+func TestScoreSaturatesOnLargeGrid(t *testing.T) {
+	const n = 41
+
+	trees := make(Trees, n)
+	for row := range trees {
+		trees[row] = make([]int, n)
+	}
+	trees[n/2][n/2] = 1
+
+	set := make(DirectionSet, 0)
+	for i := 0; i < 1000; i++ {
+		set = append(set, CardinalDirections...)
+	}
+
+	sight, err := trees.Sight(set)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := sight.Score(n/2, n/2); got != MaxScore {
+		t.Fatalf("score in the middle = %d, want %d", got, MaxScore)
+	}
+
+	for row := range trees {
+		for col := range trees[row] {
+			got := sight.Score(row, col)
+			if got < 0 {
+				t.Fatalf("negative score at %d,%d: %d", row, col, got)
+			}
+
+			if want := trees.VisibilityScore(row, col, set); got != want {
+				t.Fatalf("score at %d,%d = %d, want %d", row, col, got, want)
+			}
+		}
+	}
+}
@@ -116,59 +116,79 @@ func (p *Position) IsAdjacent(other *Position) bool {
 	return p.Distance(other) < 2.0
 }
 
-func PartOne(movements []*Movement) {
-	tailPositions := make(map[string]int, 0)
-
-	headPos := &Position{X: 0, Y: 0}
-	tailPos := &Position{X: 0, Y: 0}
-
-	tailPositions[tailPos.String()]++
+// simulate runs the movements on a rope and returns it.
+func simulate(movements []*Movement, numKnots int, rule FollowRule) *Rope {
+	rope, err := NewRope(numKnots, rule)
+	if err != nil {
+		log.Fatal(err)
+	}
 
 	for _, m := range movements {
-		for s := 0; s < m.Steps; s++ {
-			headPos.Move(m.Direction)
-
-			if !tailPos.IsAdjacent(headPos) {
-				tailPos.Follow(headPos)
-				tailPositions[tailPos.String()]++
-			}
-		}
+		rope.Move(m)
 	}
 
-	fmt.Printf("Part 1: %d\n", len(tailPositions))
+	return rope
+}
+
+func PartOne(movements []*Movement) {
+	rope := simulate(movements, 2, &KingFollow{})
+	fmt.Printf("Part 1: %d\n", rope.VisitedCount(rope.Tail()))
 }
 
 func PartTwo(movements []*Movement) {
 	const numberOfKnots = 10
 
-	knots := make([]*Position, 0, numberOfKnots)
-	for i := 0; i < numberOfKnots; i++ {
-		knots = append(knots, &Position{X: 0, Y: 0})
+	rope := simulate(movements, numberOfKnots, &KingFollow{})
+	fmt.Printf("Part 2: %d\n", rope.VisitedCount(rope.Tail()))
+}
+
+// SimulateCustom runs a rope set up with -knots, -follow and -knot, printing
+// how many cells the chosen knot visited.
+func SimulateCustom(movements []*Movement) error {
+	numKnots := 10
+	if knotsStr, ok := input.FlagValue("knots"); ok {
+		n, err := strconv.Atoi(knotsStr)
+		if err != nil {
+			return fmt.Errorf("knots is not a number: %s", knotsStr)
+		}
+
+		numKnots = n
 	}
 
-	tailPositions := make(map[string]int, 0)
-	tailPositions[knots[len(knots)-1].String()]++
+	var rule FollowRule = &KingFollow{}
+	if ruleStr, ok := input.FlagValue("follow"); ok {
+		var err error
+		if rule, err = ParseFollowRule(ruleStr); err != nil {
+			return err
+		}
+	}
+
+	rope, err := NewRope(numKnots, rule)
+	if err != nil {
+		return err
+	}
 
 	for _, m := range movements {
-		for s := 0; s < m.Steps; s++ {
-			head := knots[0]
-			head.Move(m.Direction)
-
-			for i := 1; i < len(knots); i++ {
-				cur := knots[i]
-				prev := knots[i-1]
-
-				if !cur.IsAdjacent(prev) {
-					cur.Follow(prev)
-					if i == len(knots)-1 {
-						tailPositions[cur.String()]++
-					}
-				}
-			}
+		rope.Move(m)
+	}
+
+	knot := rope.Tail()
+	if knotStr, ok := input.FlagValue("knot"); ok {
+		n, err := strconv.Atoi(knotStr)
+		if err != nil || n < 0 || n > rope.Tail() {
+			return fmt.Errorf("knot should be a number from 0 to %d: %s", rope.Tail(), knotStr)
 		}
+
+		knot = n
+	}
+
+	fmt.Printf("Rope of %d knots (%s): knot %d visited %d cells\n", numKnots, rule.Name(), knot, rope.VisitedCount(knot))
+
+	if input.HasFlag("render") {
+		fmt.Println(rope.RenderVisited(knot))
 	}
 
-	fmt.Printf("Part 2: %d\n", len(tailPositions))
+	return nil
 }
 
 func readInput() ([]*Movement, error) {
@@ -215,4 +235,14 @@ func main() {
 
 	PartOne(movements)
 	PartTwo(movements)
+
+	_, knotsSet := input.FlagValue("knots")
+	_, followSet := input.FlagValue("follow")
+	_, knotSet := input.FlagValue("knot")
+
+	if knotsSet || followSet || knotSet || input.HasFlag("render") {
+		if err := SimulateCustom(movements); err != nil {
+			log.Fatal(err)
+		}
+	}
 }
@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FollowRule decides how a knot moves after the one before it, the leader,
+// has moved from leaderPrev.
+type FollowRule interface {
+	Name() string
+	Follow(knot *Position, leader, leaderPrev Position)
+}
+
+// KingFollow keeps the knot touching its leader, diagonals included, moving
+// it one step in any of the 8 directions when they get apart.
+type KingFollow struct{}
+
+func (*KingFollow) Name() string {
+	return "king"
+}
+
+func (*KingFollow) Follow(knot *Position, leader, _ Position) {
+	if !knot.IsAdjacent(&leader) {
+		knot.Follow(&leader)
+	}
+}
+
+// OrthogonalFollow keeps the knot right next to its leader, never in a
+// diagonal, moving it one step along the axis where they are the most apart.
+type OrthogonalFollow struct{}
+
+func (*OrthogonalFollow) Name() string {
+	return "orthogonal"
+}
+
+func (*OrthogonalFollow) Follow(knot *Position, leader, _ Position) {
+	dx, dy := leader.X-knot.X, leader.Y-knot.Y
+	if abs(dx)+abs(dy) <= 1 {
+		return
+	}
+
+	if abs(dx) >= abs(dy) {
+		knot.X += sign(dx)
+	} else {
+		knot.Y += sign(dy)
+	}
+}
+
+// LaggingFollow moves the knot to where its leader was when they stop
+// touching, like the body of a snake.
+type LaggingFollow struct{}
+
+func (*LaggingFollow) Name() string {
+	return "lagging"
+}
+
+func (*LaggingFollow) Follow(knot *Position, leader, leaderPrev Position) {
+	if !knot.IsAdjacent(&leader) {
+		*knot = leaderPrev
+	}
+}
+
+func ParseFollowRule(s string) (FollowRule, error) {
+	switch s {
+	case "king":
+		return &KingFollow{}, nil
+
+	case "orthogonal":
+		return &OrthogonalFollow{}, nil
+
+	case "lagging":
+		return &LaggingFollow{}, nil
+
+	default:
+		return nil, fmt.Errorf("unknown follow rule (expected king, orthogonal or lagging): %s", s)
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+
+	return n
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+
+	case n < 0:
+		return -1
+
+	default:
+		return 0
+	}
+}
+
+// Rope is a chain of knots starting at 0,0, where the first one is the head
+// and every other one follows the one before it. It keeps the cells visited
+// by every knot.
+type Rope struct {
+	Knots []Position
+	Rule  FollowRule
+
+	visited []map[Position]bool
+	prev    []Position
+}
+
+func NewRope(numKnots int, rule FollowRule) (*Rope, error) {
+	if numKnots < 1 {
+		return nil, fmt.Errorf("a rope needs at least 1 knot, got %d", numKnots)
+	}
+
+	r := &Rope{
+		Knots:   make([]Position, numKnots),
+		Rule:    rule,
+		visited: make([]map[Position]bool, numKnots),
+		prev:    make([]Position, numKnots),
+	}
+
+	for i := range r.visited {
+		r.visited[i] = map[Position]bool{{}: true}
+	}
+
+	return r, nil
+}
+
+// Tail returns the index of the last knot.
+func (r *Rope) Tail() int {
+	return len(r.Knots) - 1
+}
+
+// Step moves the head one step and lets the rest of the knots follow it.
+func (r *Rope) Step(direction Direction) {
+	copy(r.prev, r.Knots)
+
+	r.Knots[0].Move(direction)
+	r.visited[0][r.Knots[0]] = true
+
+	for i := 1; i < len(r.Knots); i++ {
+		r.Rule.Follow(&r.Knots[i], r.Knots[i-1], r.prev[i-1])
+		r.visited[i][r.Knots[i]] = true
+	}
+}
+
+func (r *Rope) Move(m *Movement) {
+	for s := 0; s < m.Steps; s++ {
+		r.Step(m.Direction)
+	}
+}
+
+// VisitedCount returns how many different cells the knot has been at.
+func (r *Rope) VisitedCount(knot int) int {
+	return len(r.visited[knot])
+}
+
+// Visited returns the cells the knot has been at, from bottom to top and
+// left to right.
+func (r *Rope) Visited(knot int) []Position {
+	cells := make([]Position, 0, len(r.visited[knot]))
+	for p := range r.visited[knot] {
+		cells = append(cells, p)
+	}
+
+	sort.Slice(cells, func(i, j int) bool {
+		if cells[i].Y != cells[j].Y {
+			return cells[i].Y < cells[j].Y
+		}
+
+		return cells[i].X < cells[j].X
+	})
+
+	return cells
+}
+
+// RenderVisited draws the cells the knot has been at with #, and the start
+// with s, with the top row first.
+func (r *Rope) RenderVisited(knot int) string {
+	cells := r.Visited(knot)
+
+	minX, maxX, minY, maxY := 0, 0, 0, 0
+	for _, p := range cells {
+		if p.X < minX {
+			minX = p.X
+		}
+		if p.X > maxX {
+			maxX = p.X
+		}
+		if p.Y < minY {
+			minY = p.Y
+		}
+		if p.Y > maxY {
+			maxY = p.Y
+		}
+	}
+
+	lines := make([]string, 0, maxY-minY+1)
+	row := make([]byte, maxX-minX+1)
+
+	for y := maxY; y >= minY; y-- {
+		for x := minX; x <= maxX; x++ {
+			switch {
+			case x == 0 && y == 0:
+				row[x-minX] = 's'
+
+			case r.visited[knot][Position{X: x, Y: y}]:
+				row[x-minX] = '#'
+
+			default:
+				row[x-minX] = '.'
+			}
+		}
+
+		lines = append(lines, string(row))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -3,20 +3,38 @@ package input
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path"
+	"strings"
 )
 
-func getFilePath(name string) string {
-	useTest := false
+// HasFlag reports whether the boolean flag -name was given in the command line.
+func HasFlag(name string) bool {
+	for _, arg := range os.Args[1:] {
+		if arg == "-"+name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FlagValue returns the value of a flag given as -name=value in the command line.
+func FlagValue(name string) (string, bool) {
+	prefix := "-" + name + "="
 	for _, arg := range os.Args[1:] {
-		if arg == "-test" {
-			useTest = true
+		if strings.HasPrefix(arg, prefix) {
+			return arg[len(prefix):], true
 		}
 	}
 
-	if useTest {
+	return "", false
+}
+
+func getFilePath(name string) string {
+	if HasFlag("test") {
 		name += "_test"
 	}
 
@@ -68,7 +86,13 @@ func ReadChars(name string, processChar ProcessCharFunc) error {
 	}
 	defer closeFile(f)
 
-	scanner := bufio.NewScanner(f)
+	return ScanChars(f, processChar)
+}
+
+// ScanChars works like ReadChars but reads from any reader, one rune at a
+// time, so it can be used on streams like stdin.
+func ScanChars(r io.Reader, processChar ProcessCharFunc) error {
+	scanner := bufio.NewScanner(r)
 	scanner.Split(bufio.ScanRunes)
 
 	for scanner.Scan() {
@@ -0,0 +1,139 @@
+package interval
+
+import (
+	"fmt"
+)
+
+// Interval is a half-open range of integers [Start, End). Any interval with
+// End <= Start is empty, and every empty interval is normalised to the zero
+// value so they all compare equal.
+type Interval struct {
+	Start int
+	End   int
+}
+
+// HalfOpen returns the interval [start, end).
+func HalfOpen(start, end int) Interval {
+	if end <= start {
+		return Interval{}
+	}
+
+	return Interval{Start: start, End: end}
+}
+
+// Closed returns the interval [start, end], which is [start, end+1) once
+// normalised.
+func Closed(start, end int) Interval {
+	return HalfOpen(start, end+1)
+}
+
+func (i Interval) IsEmpty() bool {
+	return i.End <= i.Start
+}
+
+// Len returns the number of integers in the interval.
+func (i Interval) Len() int {
+	if i.IsEmpty() {
+		return 0
+	}
+
+	return i.End - i.Start
+}
+
+// Last returns the last integer of the interval, that is, its closed end.
+func (i Interval) Last() int {
+	return i.End - 1
+}
+
+func (i Interval) Contains(n int) bool {
+	return n >= i.Start && n < i.End
+}
+
+// ContainsInterval reports whether every integer of other is also in i. The
+// empty interval is contained in any interval.
+func (i Interval) ContainsInterval(other Interval) bool {
+	if other.IsEmpty() {
+		return true
+	}
+
+	return other.Start >= i.Start && other.End <= i.End
+}
+
+func (i Interval) Overlaps(other Interval) bool {
+	return !i.Intersect(other).IsEmpty()
+}
+
+func (i Interval) Intersect(other Interval) Interval {
+	return HalfOpen(max(i.Start, other.Start), min(i.End, other.End))
+}
+
+// Union returns the intervals covering i and other: one if they overlap or
+// are adjacent, two sorted ones otherwise.
+func (i Interval) Union(other Interval) []Interval {
+	switch {
+	case i.IsEmpty() && other.IsEmpty():
+		return []Interval{}
+
+	case i.IsEmpty():
+		return []Interval{other}
+
+	case other.IsEmpty():
+		return []Interval{i}
+	}
+
+	if i.Start > other.Start {
+		i, other = other, i
+	}
+
+	if other.Start <= i.End {
+		return []Interval{HalfOpen(i.Start, max(i.End, other.End))}
+	}
+
+	return []Interval{i, other}
+}
+
+// Subtract returns the parts of i not covered by other, sorted.
+func (i Interval) Subtract(other Interval) []Interval {
+	if !i.Overlaps(other) {
+		if i.IsEmpty() {
+			return []Interval{}
+		}
+
+		return []Interval{i}
+	}
+
+	result := make([]Interval, 0, 2)
+	if left := HalfOpen(i.Start, other.Start); !left.IsEmpty() {
+		result = append(result, left)
+	}
+
+	if right := HalfOpen(other.End, i.End); !right.IsEmpty() {
+		result = append(result, right)
+	}
+
+	return result
+}
+
+func (i Interval) String() string {
+	if i.IsEmpty() {
+		return "[)"
+	}
+
+	return fmt.Sprintf("[%d,%d)", i.Start, i.End)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
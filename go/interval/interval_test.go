@@ -0,0 +1,142 @@
+package interval
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// randomInterval returns an interval around [0, 30), which is empty now and
+// then.
+func randomInterval(r *rand.Rand) Interval {
+	start := r.Intn(35) - 5
+	return HalfOpen(start, start+r.Intn(12)-2)
+}
+
+// points returns the integers of the intervals, as a set.
+func points(intervals ...Interval) map[int]bool {
+	set := make(map[int]bool, 0)
+	for _, i := range intervals {
+		for n := i.Start; n < i.End; n++ {
+			set[n] = true
+		}
+	}
+
+	return set
+}
+
+// runs returns the set of integers as sorted, non-adjacent intervals.
+func runs(set map[int]bool) []Interval {
+	result := make([]Interval, 0)
+	for n := -10; n < 50; n++ {
+		if !set[n] {
+			continue
+		}
+
+		if last := len(result) - 1; last >= 0 && result[last].End == n {
+			result[last].End++
+		} else {
+			result = append(result, HalfOpen(n, n+1))
+		}
+	}
+
+	return result
+}
+
+func TestIntervalNormalises(t *testing.T) {
+	tests := []struct {
+		got  Interval
+		want Interval
+	}{
+		{HalfOpen(2, 5), Interval{Start: 2, End: 5}},
+		{HalfOpen(5, 5), Interval{}},
+		{HalfOpen(5, 2), Interval{}},
+		{Closed(2, 5), Interval{Start: 2, End: 6}},
+		{Closed(5, 5), Interval{Start: 5, End: 6}},
+		{Closed(5, 4), Interval{}},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got %v, want %v", tt.got, tt.want)
+		}
+	}
+}
+
+func TestIntervalOperations(t *testing.T) {
+	tests := []struct {
+		a, b      Interval
+		intersect Interval
+		union     []Interval
+		subtract  []Interval
+	}{
+		{HalfOpen(0, 5), HalfOpen(3, 8), HalfOpen(3, 5), []Interval{HalfOpen(0, 8)}, []Interval{HalfOpen(0, 3)}},
+		{HalfOpen(0, 5), HalfOpen(5, 8), Interval{}, []Interval{HalfOpen(0, 8)}, []Interval{HalfOpen(0, 5)}},
+		{HalfOpen(5, 8), HalfOpen(0, 3), Interval{}, []Interval{HalfOpen(0, 3), HalfOpen(5, 8)}, []Interval{HalfOpen(5, 8)}},
+		{HalfOpen(0, 10), HalfOpen(3, 5), HalfOpen(3, 5), []Interval{HalfOpen(0, 10)}, []Interval{HalfOpen(0, 3), HalfOpen(5, 10)}},
+		{HalfOpen(3, 5), HalfOpen(0, 10), HalfOpen(3, 5), []Interval{HalfOpen(0, 10)}, []Interval{}},
+		{HalfOpen(3, 5), Interval{}, Interval{}, []Interval{HalfOpen(3, 5)}, []Interval{HalfOpen(3, 5)}},
+		{Interval{}, HalfOpen(3, 5), Interval{}, []Interval{HalfOpen(3, 5)}, []Interval{}},
+		{Interval{}, Interval{}, Interval{}, []Interval{}, []Interval{}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.a.Intersect(tt.b); got != tt.intersect {
+			t.Errorf("%v.Intersect(%v) = %v, want %v", tt.a, tt.b, got, tt.intersect)
+		}
+
+		if got := tt.a.Union(tt.b); !reflect.DeepEqual(got, tt.union) {
+			t.Errorf("%v.Union(%v) = %v, want %v", tt.a, tt.b, got, tt.union)
+		}
+
+		if got := tt.a.Subtract(tt.b); !reflect.DeepEqual(got, tt.subtract) {
+			t.Errorf("%v.Subtract(%v) = %v, want %v", tt.a, tt.b, got, tt.subtract)
+		}
+	}
+}
+
+func TestIntervalOperationsMatchPoints(t *testing.T) {
+	r := rand.New(rand.NewSource(2022))
+
+	for i := 0; i < 5000; i++ {
+		a, b := randomInterval(r), randomInterval(r)
+		pa, pb := points(a), points(b)
+
+		both, either, onlyA := make(map[int]bool, 0), make(map[int]bool, 0), make(map[int]bool, 0)
+		for n := range pa {
+			either[n] = true
+			if pb[n] {
+				both[n] = true
+			} else {
+				onlyA[n] = true
+			}
+		}
+		for n := range pb {
+			either[n] = true
+		}
+
+		if got, want := points(a.Intersect(b)), both; !reflect.DeepEqual(got, want) {
+			t.Fatalf("%v.Intersect(%v) = %v", a, b, a.Intersect(b))
+		}
+
+		if got, want := a.Overlaps(b), len(both) > 0; got != want {
+			t.Fatalf("%v.Overlaps(%v) = %t, want %t", a, b, got, want)
+		}
+
+		if got, want := a.ContainsInterval(b), len(both) == len(pb); got != want {
+			t.Fatalf("%v.ContainsInterval(%v) = %t, want %t", a, b, got, want)
+		}
+
+		if got, want := a.Union(b), runs(either); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%v.Union(%v) = %v, want %v", a, b, got, want)
+		}
+
+		if got, want := a.Subtract(b), runs(onlyA); !reflect.DeepEqual(got, want) {
+			t.Fatalf("%v.Subtract(%v) = %v, want %v", a, b, got, want)
+		}
+
+		if got, want := a.Len(), len(pa); got != want {
+			t.Fatalf("%v.Len() = %d, want %d", a, got, want)
+		}
+	}
+}
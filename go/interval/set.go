@@ -0,0 +1,165 @@
+package interval
+
+import (
+	"sort"
+	"strings"
+)
+
+// Set is a set of integers stored as sorted, non-overlapping and non-adjacent
+// intervals. The zero value is an empty set ready to use.
+type Set struct {
+	intervals []Interval
+}
+
+func NewSet(intervals ...Interval) *Set {
+	s := &Set{}
+	for _, i := range intervals {
+		s.Add(i)
+	}
+
+	return s
+}
+
+// Intervals returns a copy of the merged intervals of the set, sorted.
+func (s *Set) Intervals() []Interval {
+	cp := make([]Interval, len(s.intervals))
+	copy(cp, s.intervals)
+	return cp
+}
+
+// Len returns the number of integers in the set.
+func (s *Set) Len() (r int) {
+	for _, i := range s.intervals {
+		r += i.Len()
+	}
+
+	return r
+}
+
+func (s *Set) IsEmpty() bool {
+	return len(s.intervals) == 0
+}
+
+func (s *Set) Contains(n int) bool {
+	idx := s.search(n)
+	return idx < len(s.intervals) && s.intervals[idx].Contains(n)
+}
+
+// ContainsInterval reports whether the whole interval is in the set.
+func (s *Set) ContainsInterval(i Interval) bool {
+	if i.IsEmpty() {
+		return true
+	}
+
+	idx := s.search(i.Start)
+	return idx < len(s.intervals) && s.intervals[idx].ContainsInterval(i)
+}
+
+// Overlaps reports whether any integer of the interval is in the set.
+func (s *Set) Overlaps(i Interval) bool {
+	if i.IsEmpty() {
+		return false
+	}
+
+	idx := s.search(i.Start)
+	return idx < len(s.intervals) && s.intervals[idx].Overlaps(i)
+}
+
+// Add inserts the interval, merging it with any overlapping or adjacent ones.
+func (s *Set) Add(i Interval) {
+	if i.IsEmpty() {
+		return
+	}
+
+	// first interval that could touch i, that is, the first one ending at or
+	// after its start
+	lo := sort.Search(len(s.intervals), func(idx int) bool {
+		return s.intervals[idx].End >= i.Start
+	})
+
+	hi := lo
+	for hi < len(s.intervals) && s.intervals[hi].Start <= i.End {
+		i = i.Union(s.intervals[hi])[0]
+		hi++
+	}
+
+	s.replace(lo, hi, i)
+}
+
+// Remove deletes every integer of the interval from the set.
+func (s *Set) Remove(i Interval) {
+	if i.IsEmpty() {
+		return
+	}
+
+	lo := s.search(i.Start)
+
+	hi := lo
+	remaining := make([]Interval, 0, 2)
+	for hi < len(s.intervals) && s.intervals[hi].Start < i.End {
+		remaining = append(remaining, s.intervals[hi].Subtract(i)...)
+		hi++
+	}
+
+	s.replace(lo, hi, remaining...)
+}
+
+func (s *Set) Union(other *Set) *Set {
+	r := &Set{intervals: s.Intervals()}
+	for _, i := range other.intervals {
+		r.Add(i)
+	}
+
+	return r
+}
+
+func (s *Set) Intersect(other *Set) *Set {
+	r := &Set{}
+
+	a, b := 0, 0
+	for a < len(s.intervals) && b < len(other.intervals) {
+		ia, ib := s.intervals[a], other.intervals[b]
+		if i := ia.Intersect(ib); !i.IsEmpty() {
+			r.intervals = append(r.intervals, i)
+		}
+
+		if ia.End < ib.End {
+			a++
+		} else {
+			b++
+		}
+	}
+
+	return r
+}
+
+func (s *Set) Subtract(other *Set) *Set {
+	r := &Set{intervals: s.Intervals()}
+	for _, i := range other.intervals {
+		r.Remove(i)
+	}
+
+	return r
+}
+
+func (s *Set) String() string {
+	parts := make([]string, 0, len(s.intervals))
+	for _, i := range s.intervals {
+		parts = append(parts, i.String())
+	}
+
+	return "{" + strings.Join(parts, " ") + "}"
+}
+
+// search returns the index of the first interval ending after n.
+func (s *Set) search(n int) int {
+	return sort.Search(len(s.intervals), func(idx int) bool {
+		return s.intervals[idx].End > n
+	})
+}
+
+// replace swaps the intervals in [lo, hi) for the given ones.
+func (s *Set) replace(lo, hi int, intervals ...Interval) {
+	tail := append(intervals, s.intervals[hi:]...)
+	s.intervals = append(s.intervals[:lo], tail...)
+}
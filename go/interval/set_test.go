@@ -0,0 +1,155 @@
+package interval
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSetChanges(t *testing.T) {
+	s := NewSet()
+
+	steps := []struct {
+		name   string
+		change func()
+		want   []Interval
+	}{
+		{"add to an empty set", func() { s.Add(HalfOpen(10, 20)) }, []Interval{HalfOpen(10, 20)}},
+		{"add an empty interval", func() { s.Add(Interval{}) }, []Interval{HalfOpen(10, 20)}},
+		{"add a disjoint interval before", func() { s.Add(HalfOpen(0, 5)) }, []Interval{HalfOpen(0, 5), HalfOpen(10, 20)}},
+		{"add an adjacent interval", func() { s.Add(HalfOpen(20, 25)) }, []Interval{HalfOpen(0, 5), HalfOpen(10, 25)}},
+		{"add an interval joining two", func() { s.Add(HalfOpen(4, 11)) }, []Interval{HalfOpen(0, 25)}},
+		{"remove from the middle", func() { s.Remove(HalfOpen(5, 10)) }, []Interval{HalfOpen(0, 5), HalfOpen(10, 25)}},
+		{"remove across two intervals", func() { s.Remove(HalfOpen(3, 12)) }, []Interval{HalfOpen(0, 3), HalfOpen(12, 25)}},
+		{"remove outside the set", func() { s.Remove(HalfOpen(30, 40)) }, []Interval{HalfOpen(0, 3), HalfOpen(12, 25)}},
+		{"remove a whole interval", func() { s.Remove(HalfOpen(-5, 3)) }, []Interval{HalfOpen(12, 25)}},
+		{"remove everything", func() { s.Remove(HalfOpen(0, 100)) }, []Interval{}},
+	}
+
+	for _, step := range steps {
+		step.change()
+
+		if got := s.Intervals(); !reflect.DeepEqual(got, step.want) {
+			t.Fatalf("%s: got %v, want %v", step.name, got, step.want)
+		}
+	}
+}
+
+func TestSetOperations(t *testing.T) {
+	a := NewSet(HalfOpen(0, 10), HalfOpen(20, 30))
+	b := NewSet(HalfOpen(5, 25), HalfOpen(28, 40))
+
+	tests := []struct {
+		name string
+		got  *Set
+		want []Interval
+	}{
+		{"union", a.Union(b), []Interval{HalfOpen(0, 40)}},
+		{"intersect", a.Intersect(b), []Interval{HalfOpen(5, 10), HalfOpen(20, 25), HalfOpen(28, 30)}},
+		{"subtract", a.Subtract(b), []Interval{HalfOpen(0, 5), HalfOpen(25, 28)}},
+		{"subtract the other way", b.Subtract(a), []Interval{HalfOpen(10, 20), HalfOpen(30, 40)}},
+		{"intersect with an empty set", a.Intersect(NewSet()), []Interval{}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.got.Intervals(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// the operations must leave their operands alone
+	if got, want := a.Intervals(), []Interval{HalfOpen(0, 10), HalfOpen(20, 30)}; !reflect.DeepEqual(got, want) {
+		t.Errorf("a changed to %v", got)
+	}
+}
+
+// randomSet applies random changes to a set and to the integers it should
+// hold, and returns both.
+func randomSet(r *rand.Rand) (*Set, map[int]bool) {
+	s := NewSet()
+	want := make(map[int]bool, 0)
+
+	for i := r.Intn(10); i > 0; i-- {
+		in := randomInterval(r)
+
+		if r.Intn(3) == 0 {
+			s.Remove(in)
+			for n := range points(in) {
+				delete(want, n)
+			}
+		} else {
+			s.Add(in)
+			for n := range points(in) {
+				want[n] = true
+			}
+		}
+	}
+
+	return s, want
+}
+
+// checkSet fails if the set does not hold exactly the given integers.
+func checkSet(t *testing.T, s *Set, want map[int]bool) {
+	t.Helper()
+
+	if got, want := s.Intervals(), runs(want); !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+
+	if got, want := s.Len(), len(want); got != want {
+		t.Fatalf("%v: Len() = %d, want %d", s, got, want)
+	}
+
+	if got, want := s.IsEmpty(), len(want) == 0; got != want {
+		t.Fatalf("%v: IsEmpty() = %t, want %t", s, got, want)
+	}
+}
+
+func TestSetMatchesPoints(t *testing.T) {
+	r := rand.New(rand.NewSource(2022))
+
+	for i := 0; i < 2000; i++ {
+		a, pa := randomSet(r)
+		b, pb := randomSet(r)
+		checkSet(t, a, pa)
+
+		for n := -10; n < 50; n++ {
+			if got := a.Contains(n); got != pa[n] {
+				t.Fatalf("%v.Contains(%d) = %t, want %t", a, n, got, pa[n])
+			}
+		}
+
+		in := randomInterval(r)
+		covered := 0
+		for n := range points(in) {
+			if pa[n] {
+				covered++
+			}
+		}
+
+		if got, want := a.ContainsInterval(in), covered == in.Len(); got != want {
+			t.Fatalf("%v.ContainsInterval(%v) = %t, want %t", a, in, got, want)
+		}
+
+		if got, want := a.Overlaps(in), covered > 0; got != want {
+			t.Fatalf("%v.Overlaps(%v) = %t, want %t", a, in, got, want)
+		}
+
+		both, either, onlyA := make(map[int]bool, 0), make(map[int]bool, 0), make(map[int]bool, 0)
+		for n := range pa {
+			either[n] = true
+			if pb[n] {
+				both[n] = true
+			} else {
+				onlyA[n] = true
+			}
+		}
+		for n := range pb {
+			either[n] = true
+		}
+
+		checkSet(t, a.Union(b), either)
+		checkSet(t, a.Intersect(b), both)
+		checkSet(t, a.Subtract(b), onlyA)
+	}
+}
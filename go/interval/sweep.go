@@ -0,0 +1,150 @@
+package interval
+
+import (
+	"sort"
+)
+
+// Overlap is a pair of entries whose intervals share at least one integer.
+// A is always the entry that started first.
+type Overlap[T any] struct {
+	A            Entry[T]
+	B            Entry[T]
+	Intersection Interval
+}
+
+type SweepResult[T any] struct {
+	// Span goes from the smallest start to the biggest end of all entries,
+	// widened to the bounds of the sweep if there are any.
+	Span Interval
+	// Covered is the number of integers covered by at least one entry.
+	Covered int
+	// MaxDepth is the maximum number of entries covering the same integer,
+	// first reached at MaxDepthAt.
+	MaxDepth   int
+	MaxDepthAt Interval
+	// Gaps are the parts of the span not covered by any entry, sorted.
+	Gaps []Interval
+	// Overlaps holds every overlapping pair of entries, in sweep order.
+	Overlaps []Overlap[T]
+}
+
+type sweepEvent struct {
+	pos   int
+	start bool
+	entry int
+}
+
+// Sweep analyses all the entries at once with a sweep line over their starts
+// and ends. Sorting the events takes O(N log N) and every overlapping pair is
+// found in constant time when its second entry starts, so the whole sweep
+// runs in O(N log N + K) for K overlapping pairs.
+func Sweep[T any](entries []Entry[T]) *SweepResult[T] {
+	return SweepWithin(entries, Interval{})
+}
+
+// SweepWithin works like Sweep, but the span covers the bounds too, so the
+// parts of the bounds before the first entry or after the last one are
+// reported as gaps. Empty bounds give the same result as Sweep.
+func SweepWithin[T any](entries []Entry[T], bounds Interval) *SweepResult[T] {
+	r := &SweepResult[T]{
+		Gaps:     make([]Interval, 0),
+		Overlaps: make([]Overlap[T], 0),
+	}
+
+	events := make([]sweepEvent, 0, len(entries)*2)
+	for idx, e := range entries {
+		if e.Interval.IsEmpty() {
+			continue
+		}
+
+		events = append(events,
+			sweepEvent{pos: e.Interval.Start, start: true, entry: idx},
+			sweepEvent{pos: e.Interval.End, start: false, entry: idx},
+		)
+	}
+
+	if len(events) == 0 {
+		if !bounds.IsEmpty() {
+			r.Span = bounds
+			r.Gaps = append(r.Gaps, bounds)
+		}
+
+		return r
+	}
+
+	// intervals are half-open, so at the same position ends go before starts
+	// and touching intervals do not overlap
+	sort.SliceStable(events, func(i, j int) bool {
+		if events[i].pos != events[j].pos {
+			return events[i].pos < events[j].pos
+		}
+
+		return !events[i].start && events[j].start
+	})
+
+	// active holds the entries covering the current position and activePos
+	// where each of them is in it, so they can be removed in constant time
+	active := make([]int, 0)
+	activePos := make(map[int]int, 0)
+
+	r.Span = HalfOpen(events[0].pos, events[len(events)-1].pos)
+	if !bounds.IsEmpty() {
+		r.Span = HalfOpen(min(r.Span.Start, bounds.Start), max(r.Span.End, bounds.End))
+	}
+
+	if r.Span.Start < events[0].pos {
+		r.Gaps = append(r.Gaps, HalfOpen(r.Span.Start, events[0].pos))
+	}
+
+	prevPos := events[0].pos
+
+	for _, ev := range events {
+		if ev.pos > prevPos {
+			section := HalfOpen(prevPos, ev.pos)
+
+			if depth := len(active); depth == 0 {
+				r.Gaps = append(r.Gaps, section)
+			} else {
+				r.Covered += section.Len()
+
+				if depth > r.MaxDepth {
+					r.MaxDepth = depth
+					r.MaxDepthAt = section
+				}
+			}
+
+			prevPos = ev.pos
+		}
+
+		if !ev.start {
+			pos := activePos[ev.entry]
+			last := active[len(active)-1]
+
+			active[pos] = last
+			activePos[last] = pos
+			active = active[:len(active)-1]
+			delete(activePos, ev.entry)
+
+			continue
+		}
+
+		e := entries[ev.entry]
+		for _, other := range active {
+			o := entries[other]
+			r.Overlaps = append(r.Overlaps, Overlap[T]{
+				A:            o,
+				B:            e,
+				Intersection: o.Interval.Intersect(e.Interval),
+			})
+		}
+
+		activePos[ev.entry] = len(active)
+		active = append(active, ev.entry)
+	}
+
+	if prevPos < r.Span.End {
+		r.Gaps = append(r.Gaps, HalfOpen(prevPos, r.Span.End))
+	}
+
+	return r
+}
@@ -0,0 +1,162 @@
+package interval
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestSweepWithin(t *testing.T) {
+	entries := []Entry[string]{
+		{Interval: HalfOpen(2, 6), Value: "a"},
+		{Interval: HalfOpen(4, 8), Value: "b"},
+		{Interval: HalfOpen(10, 12), Value: "c"},
+	}
+
+	tests := []struct {
+		name    string
+		entries []Entry[string]
+		bounds  Interval
+		span    Interval
+		gaps    []Interval
+	}{
+		{"no bounds", entries, Interval{}, HalfOpen(2, 12), []Interval{HalfOpen(8, 10)}},
+		{"wider bounds", entries, HalfOpen(0, 15), HalfOpen(0, 15), []Interval{HalfOpen(0, 2), HalfOpen(8, 10), HalfOpen(12, 15)}},
+		{"narrower bounds", entries, HalfOpen(5, 9), HalfOpen(2, 12), []Interval{HalfOpen(8, 10)}},
+		{"bounds without entries", nil, HalfOpen(1, 4), HalfOpen(1, 4), []Interval{HalfOpen(1, 4)}},
+		{"nothing at all", nil, Interval{}, Interval{}, []Interval{}},
+	}
+
+	for _, tt := range tests {
+		r := SweepWithin(tt.entries, tt.bounds)
+
+		if r.Span != tt.span {
+			t.Errorf("%s: span = %v, want %v", tt.name, r.Span, tt.span)
+		}
+
+		if !reflect.DeepEqual(r.Gaps, tt.gaps) {
+			t.Errorf("%s: gaps = %v, want %v", tt.name, r.Gaps, tt.gaps)
+		}
+	}
+
+	r := Sweep(entries)
+	if r.Covered != 8 || r.MaxDepth != 2 || r.MaxDepthAt != HalfOpen(4, 6) || len(r.Overlaps) != 1 {
+		t.Errorf("got covered %d, max depth %d at %v and %d overlaps", r.Covered, r.MaxDepth, r.MaxDepthAt, len(r.Overlaps))
+	}
+}
+
+// sweepPoints works out what the sweep should find by counting the entries
+// covering every integer.
+func sweepPoints(entries []Entry[int], bounds Interval) *SweepResult[int] {
+	want := &SweepResult[int]{
+		Gaps:     make([]Interval, 0),
+		Overlaps: make([]Overlap[int], 0),
+	}
+
+	depth := make(map[int]int, 0)
+	events := make(map[int]bool, 0)
+	for _, e := range entries {
+		if e.Interval.IsEmpty() {
+			continue
+		}
+
+		for n := range points(e.Interval) {
+			depth[n]++
+		}
+
+		events[e.Interval.Start], events[e.Interval.End] = true, true
+
+		if want.Span.IsEmpty() {
+			want.Span = e.Interval
+		} else {
+			want.Span = HalfOpen(min(want.Span.Start, e.Interval.Start), max(want.Span.End, e.Interval.End))
+		}
+	}
+
+	if !bounds.IsEmpty() {
+		if want.Span.IsEmpty() {
+			want.Span = bounds
+		} else {
+			want.Span = HalfOpen(min(want.Span.Start, bounds.Start), max(want.Span.End, bounds.End))
+		}
+	}
+
+	uncovered := make(map[int]bool, 0)
+	for n := want.Span.Start; n < want.Span.End; n++ {
+		if depth[n] == 0 {
+			uncovered[n] = true
+			continue
+		}
+
+		want.Covered++
+
+		if depth[n] > want.MaxDepth {
+			// the section where it is reached goes on until the next event
+			end := n + 1
+			for !events[end] {
+				end++
+			}
+
+			want.MaxDepth = depth[n]
+			want.MaxDepthAt = HalfOpen(n, end)
+		}
+	}
+	want.Gaps = runs(uncovered)
+
+	return want
+}
+
+func TestSweepMatchesPoints(t *testing.T) {
+	r := rand.New(rand.NewSource(2022))
+
+	for i := 0; i < 2000; i++ {
+		entries := randomEntries(r, r.Intn(15))
+		bounds := Interval{}
+		if r.Intn(2) == 0 {
+			bounds = randomInterval(r)
+		}
+
+		got, want := SweepWithin(entries, bounds), sweepPoints(entries, bounds)
+
+		if got.Span != want.Span || got.Covered != want.Covered || got.MaxDepth != want.MaxDepth || got.MaxDepthAt != want.MaxDepthAt {
+			t.Fatalf("got span %v, covered %d, max depth %d at %v, want span %v, covered %d, max depth %d at %v in %v within %v",
+				got.Span, got.Covered, got.MaxDepth, got.MaxDepthAt,
+				want.Span, want.Covered, want.MaxDepth, want.MaxDepthAt, entries, bounds)
+		}
+
+		if !reflect.DeepEqual(got.Gaps, want.Gaps) {
+			t.Fatalf("gaps = %v, want %v in %v within %v", got.Gaps, want.Gaps, entries, bounds)
+		}
+
+		// every overlapping pair must be found exactly once, the one starting
+		// first as A
+		found := make(map[[2]int]bool, 0)
+		for _, o := range got.Overlaps {
+			a, b := o.A.Value, o.B.Value
+			if o.A.Interval.Start > o.B.Interval.Start {
+				t.Fatalf("%v starts after %v", o.A, o.B)
+			}
+
+			if o.Intersection != o.A.Interval.Intersect(o.B.Interval) || o.Intersection.IsEmpty() {
+				t.Fatalf("wrong intersection %v for %v and %v", o.Intersection, o.A, o.B)
+			}
+
+			if a > b {
+				a, b = b, a
+			}
+
+			if found[[2]int{a, b}] {
+				t.Fatalf("overlap of %d and %d found twice", a, b)
+			}
+			found[[2]int{a, b}] = true
+		}
+
+		for a := range entries {
+			for b := a + 1; b < len(entries); b++ {
+				if want := entries[a].Interval.Overlaps(entries[b].Interval); found[[2]int{a, b}] != want {
+					t.Fatalf("overlap of %v and %v found = %t, want %t", entries[a], entries[b], found[[2]int{a, b}], want)
+				}
+			}
+		}
+	}
+}
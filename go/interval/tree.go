@@ -0,0 +1,115 @@
+package interval
+
+import (
+	"sort"
+)
+
+type Entry[T any] struct {
+	Interval Interval
+	Value    T
+}
+
+// Tree is a static interval tree answering which entries overlap a given
+// interval in O(log N + K). The entries are kept sorted by start and laid out
+// as an implicit balanced binary search tree, where every node also stores
+// the maximum end of its subtree to prune the search.
+type Tree[T any] struct {
+	entries []Entry[T]
+	maxEnd  []int
+}
+
+// NewTree builds the tree in O(N log N). Empty intervals never overlap
+// anything, so they are left out.
+func NewTree[T any](entries []Entry[T]) *Tree[T] {
+	t := &Tree[T]{
+		entries: make([]Entry[T], 0, len(entries)),
+	}
+
+	for _, e := range entries {
+		if !e.Interval.IsEmpty() {
+			t.entries = append(t.entries, e)
+		}
+	}
+
+	sort.SliceStable(t.entries, func(i, j int) bool {
+		return t.entries[i].Interval.Start < t.entries[j].Interval.Start
+	})
+
+	t.maxEnd = make([]int, len(t.entries))
+	t.build(0, len(t.entries))
+
+	return t
+}
+
+func (t *Tree[T]) Len() int {
+	return len(t.entries)
+}
+
+// build fills maxEnd for the subtree over entries[lo:hi], rooted at its middle
+// entry, and returns it.
+func (t *Tree[T]) build(lo, hi int) int {
+	if lo >= hi {
+		return 0
+	}
+
+	mid := (lo + hi) / 2
+	m := t.entries[mid].Interval.End
+
+	if l := t.build(lo, mid); mid > lo && l > m {
+		m = l
+	}
+
+	if r := t.build(mid+1, hi); mid+1 < hi && r > m {
+		m = r
+	}
+
+	t.maxEnd[mid] = m
+	return m
+}
+
+// Overlapping returns the entries overlapping the interval, sorted by start.
+func (t *Tree[T]) Overlapping(i Interval) []Entry[T] {
+	result := make([]Entry[T], 0)
+	if i.IsEmpty() {
+		return result
+	}
+
+	t.query(0, len(t.entries), i, func(e Entry[T]) {
+		result = append(result, e)
+	})
+
+	return result
+}
+
+// Stabbing returns the entries containing the given point, sorted by start.
+func (t *Tree[T]) Stabbing(n int) []Entry[T] {
+	return t.Overlapping(HalfOpen(n, n+1))
+}
+
+func (t *Tree[T]) query(lo, hi int, i Interval, visit func(Entry[T])) {
+	if lo >= hi {
+		return
+	}
+
+	mid := (lo + hi) / 2
+
+	// nothing in this subtree ends after the query starts
+	if t.maxEnd[mid] <= i.Start {
+		return
+	}
+
+	t.query(lo, mid, i, visit)
+
+	e := t.entries[mid]
+
+	// this entry and everything to its right start after the query ends
+	if e.Interval.Start >= i.End {
+		return
+	}
+
+	if e.Interval.Overlaps(i) {
+		visit(e)
+	}
+
+	t.query(mid+1, hi, i, visit)
+}
@@ -0,0 +1,91 @@
+package interval
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// randomEntries returns n random entries, valued by their index.
+func randomEntries(r *rand.Rand, n int) []Entry[int] {
+	entries := make([]Entry[int], n)
+	for idx := range entries {
+		entries[idx] = Entry[int]{Interval: randomInterval(r), Value: idx}
+	}
+
+	return entries
+}
+
+// overlappingScan is the linear scan the tree replaces.
+func overlappingScan(entries []Entry[int], i Interval) []Entry[int] {
+	result := make([]Entry[int], 0)
+	for _, e := range entries {
+		if e.Interval.Overlaps(i) {
+			result = append(result, e)
+		}
+	}
+
+	sort.SliceStable(result, func(a, b int) bool {
+		return result[a].Interval.Start < result[b].Interval.Start
+	})
+
+	return result
+}
+
+func TestTreeOverlapping(t *testing.T) {
+	tree := NewTree([]Entry[string]{
+		{Interval: HalfOpen(0, 5), Value: "a"},
+		{Interval: HalfOpen(3, 8), Value: "b"},
+		{Interval: HalfOpen(8, 10), Value: "c"},
+		{Interval: Interval{}, Value: "empty"},
+		{Interval: HalfOpen(-5, 20), Value: "d"},
+	})
+
+	tests := []struct {
+		query Interval
+		want  []string
+	}{
+		{HalfOpen(4, 5), []string{"d", "a", "b"}},
+		{HalfOpen(5, 8), []string{"d", "b"}},
+		{HalfOpen(8, 9), []string{"d", "c"}},
+		{HalfOpen(20, 30), []string{}},
+		{Interval{}, []string{}},
+	}
+
+	if got := tree.Len(); got != 4 {
+		t.Fatalf("Len() = %d, want 4", got)
+	}
+
+	for _, tt := range tests {
+		got := make([]string, 0)
+		for _, e := range tree.Overlapping(tt.query) {
+			got = append(got, e.Value)
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Overlapping(%v) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestTreeMatchesScan(t *testing.T) {
+	r := rand.New(rand.NewSource(2022))
+
+	for i := 0; i < 500; i++ {
+		entries := randomEntries(r, r.Intn(40))
+		tree := NewTree(entries)
+
+		for q := 0; q < 20; q++ {
+			query := randomInterval(r)
+			if got, want := tree.Overlapping(query), overlappingScan(entries, query); !reflect.DeepEqual(got, want) {
+				t.Fatalf("Overlapping(%v) = %v, want %v in %v", query, got, want, entries)
+			}
+
+			n := r.Intn(40) - 5
+			if got, want := tree.Stabbing(n), overlappingScan(entries, HalfOpen(n, n+1)); !reflect.DeepEqual(got, want) {
+				t.Fatalf("Stabbing(%d) = %v, want %v in %v", n, got, want, entries)
+			}
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package vfs
+
+import (
+	"errors"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// FS is an in-memory filesystem. Except in the io/fs methods, paths can be
+// absolute, like "/a/b/c.txt", or relative to the root, like "a/b/c.txt".
+type FS struct {
+	root *Dir
+}
+
+func New() *FS {
+	return &FS{root: NewDir("/")}
+}
+
+// FromDir returns a filesystem rooted at an existing dir.
+func FromDir(root *Dir) *FS {
+	return &FS{root: root}
+}
+
+func (fsys *FS) Root() *Dir {
+	return fsys.root
+}
+
+// Clean returns the absolute, cleaned version of the path.
+func Clean(p string) string {
+	return path.Clean("/" + p)
+}
+
+func splitPath(p string) []string {
+	p = Clean(p)
+	if p == "/" {
+		return []string{}
+	}
+
+	return strings.Split(p[1:], "/")
+}
+
+// Lookup returns the dir or the file at the path. Only one of them is set
+// when there is no error.
+func (fsys *FS) Lookup(p string) (*Dir, *File, error) {
+	cur := fsys.root
+	parts := splitPath(p)
+
+	for i, name := range parts {
+		if sd, ok := cur.dirs[name]; ok {
+			cur = sd
+			continue
+		}
+
+		if f, ok := cur.files[name]; ok && i == len(parts)-1 {
+			return nil, f, nil
+		}
+
+		return nil, nil, &fs.PathError{Op: "lookup", Path: Clean(p), Err: fs.ErrNotExist}
+	}
+
+	return cur, nil, nil
+}
+
+// LookupDir returns the dir at the path, failing if it is a file.
+func (fsys *FS) LookupDir(p string) (*Dir, error) {
+	d, _, err := fsys.Lookup(p)
+	if err != nil {
+		return nil, err
+	}
+
+	if d == nil {
+		return nil, &fs.PathError{Op: "lookup", Path: Clean(p), Err: errNotDir}
+	}
+
+	return d, nil
+}
+
+var (
+	errNotDir   = errors.New("not a directory")
+	errNotEmpty = errors.New("directory not empty")
+)
+
+func (fsys *FS) Stat(p string) (fs.FileInfo, error) {
+	d, f, err := fsys.Lookup(p)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: Clean(p), Err: fs.ErrNotExist}
+	}
+
+	if d != nil {
+		return dirInfo(d), nil
+	}
+
+	return fileInfo(f), nil
+}
+
+// parentDir returns the dir that should contain the path, and the name of
+// the path inside it.
+func (fsys *FS) parentDir(op, p string) (*Dir, string, error) {
+	p = Clean(p)
+	if p == "/" {
+		return nil, "", &fs.PathError{Op: op, Path: p, Err: fs.ErrInvalid}
+	}
+
+	dir, name := path.Split(p)
+
+	parent, err := fsys.LookupDir(dir)
+	if err != nil {
+		return nil, "", &fs.PathError{Op: op, Path: p, Err: fs.ErrNotExist}
+	}
+
+	return parent, name, nil
+}
+
+// Create creates a file with the given size, or sets the size of the
+// existing one. Its dir must exist.
+func (fsys *FS) Create(p string, size int64) (*File, error) {
+	parent, name, err := fsys.parentDir("create", p)
+	if err != nil {
+		return nil, err
+	}
+
+	return parent.AddFile(name, size)
+}
+
+// Mkdir creates a dir, failing if it already exists or its parent does not.
+func (fsys *FS) Mkdir(p string) (*Dir, error) {
+	parent, name, err := fsys.parentDir("mkdir", p)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, err := fsys.Lookup(p); err == nil {
+		return nil, &fs.PathError{Op: "mkdir", Path: Clean(p), Err: fs.ErrExist}
+	}
+
+	return parent.AddDir(name)
+}
+
+// MkdirAll creates a dir along with any missing parents, and returns it.
+func (fsys *FS) MkdirAll(p string) (*Dir, error) {
+	cur := fsys.root
+	for _, name := range splitPath(p) {
+		sd, err := cur.AddDir(name)
+		if err != nil {
+			return nil, err
+		}
+
+		cur = sd
+	}
+
+	return cur, nil
+}
+
+// Remove deletes a file or an empty dir.
+func (fsys *FS) Remove(p string) error {
+	parent, name, err := fsys.parentDir("remove", p)
+	if err != nil {
+		return err
+	}
+
+	if sd, ok := parent.dirs[name]; ok && !sd.IsEmpty() {
+		return &fs.PathError{Op: "remove", Path: Clean(p), Err: errNotEmpty}
+	}
+
+	return parent.Remove(name)
+}
+
+// RemoveAll deletes a file or a dir with all its contents.
+func (fsys *FS) RemoveAll(p string) error {
+	parent, name, err := fsys.parentDir("remove", p)
+	if err != nil {
+		return err
+	}
+
+	return parent.Remove(name)
+}
+
+// Move renames a file or a dir. The destination must not exist, but its dir
+// must, and a dir cannot be moved inside itself.
+func (fsys *FS) Move(oldPath, newPath string) error {
+	oldParent, oldName, err := fsys.parentDir("move", oldPath)
+	if err != nil {
+		return err
+	}
+
+	newParent, newName, err := fsys.parentDir("move", newPath)
+	if err != nil {
+		return err
+	}
+
+	if _, _, err := fsys.Lookup(newPath); err == nil {
+		return &fs.PathError{Op: "move", Path: Clean(newPath), Err: fs.ErrExist}
+	}
+
+	if f, ok := oldParent.files[oldName]; ok {
+		delete(oldParent.files, oldName)
+		oldParent.grow(-f.size)
+
+		f.name = newName
+		f.parent = newParent
+		newParent.files[newName] = f
+		newParent.grow(f.size)
+		return nil
+	}
+
+	d, ok := oldParent.dirs[oldName]
+	if !ok {
+		return &fs.PathError{Op: "move", Path: Clean(oldPath), Err: fs.ErrNotExist}
+	}
+
+	for p := newParent; p != nil; p = p.parent {
+		if p == d {
+			return &fs.PathError{Op: "move", Path: Clean(newPath), Err: fs.ErrInvalid}
+		}
+	}
+
+	delete(oldParent.dirs, oldName)
+	oldParent.grow(-d.size)
+
+	d.name = newName
+	d.parent = newParent
+	newParent.dirs[newName] = d
+	newParent.grow(d.size)
+	return nil
+}
+
+// Walk calls fn for the path and everything below it, in lexical order, with
+// absolute paths. It works like fs.WalkDir, so fn can return fs.SkipDir.
+func (fsys *FS) Walk(root string, fn fs.WalkDirFunc) error {
+	return fs.WalkDir(fsys, toFSPath(root), func(p string, d fs.DirEntry, err error) error {
+		return fn(Clean(p), d, err)
+	})
+}
+
+// Glob returns the paths matching the pattern, with the syntax of
+// path.Match, sorted. Absolute patterns give absolute paths and relative ones
+// give io/fs names, so FS also implements fs.GlobFS.
+func (fsys *FS) Glob(pattern string) ([]string, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	absolute := strings.HasPrefix(pattern, "/")
+	pattern = Clean(pattern)
+	depth := len(splitPath(pattern))
+
+	matches := make([]string, 0)
+	err := fsys.Walk("/", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		pathDepth := len(splitPath(p))
+		if pathDepth == depth {
+			if ok, _ := path.Match(pattern, p); ok {
+				if !absolute {
+					p = toFSPath(p)
+				}
+
+				matches = append(matches, p)
+			}
+		}
+
+		// nothing deeper can match
+		if d.IsDir() && pathDepth >= depth {
+			return fs.SkipDir
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return matches, nil
+}
+
+// toFSPath turns a path into an io/fs one, relative to the root.
+func toFSPath(p string) string {
+	p = Clean(p)
+	if p == "/" {
+		return "."
+	}
+
+	return p[1:]
+}
@@ -0,0 +1,186 @@
+package vfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+	"time"
+)
+
+var (
+	_ fs.FS        = (*FS)(nil)
+	_ fs.StatFS    = (*FS)(nil)
+	_ fs.ReadDirFS = (*FS)(nil)
+)
+
+type info struct {
+	name  string
+	size  int64
+	isDir bool
+}
+
+func fileInfo(f *File) *info {
+	return &info{name: f.name, size: f.size}
+}
+
+func dirInfo(d *Dir) *info {
+	name := d.name
+	if d.parent == nil {
+		name = "."
+	}
+
+	return &info{name: name, size: d.Size(), isDir: true}
+}
+
+func (i *info) Name() string {
+	return i.name
+}
+
+// Size is the size of the file, or the total size of the dir contents.
+func (i *info) Size() int64 {
+	return i.size
+}
+
+func (i *info) Mode() fs.FileMode {
+	if i.isDir {
+		return fs.ModeDir | 0o555
+	}
+
+	return 0o444
+}
+
+func (i *info) ModTime() time.Time {
+	return time.Time{}
+}
+
+func (i *info) IsDir() bool {
+	return i.isDir
+}
+
+func (i *info) Sys() any {
+	return nil
+}
+
+// Open implements fs.FS, so unlike the rest of methods it only accepts io/fs
+// names.
+func (fsys *FS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	d, f, err := fsys.Lookup(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	if d != nil {
+		return &openDir{dir: d, entries: dirEntries(d)}, nil
+	}
+
+	return &openFile{file: f}, nil
+}
+
+// ReadDir implements fs.ReadDirFS, so it only accepts io/fs names.
+func (fsys *FS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+
+	d, err := fsys.LookupDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return dirEntries(d), nil
+}
+
+// dirEntries returns the contents of the dir sorted by name, as fs.ReadDir
+// does.
+func dirEntries(d *Dir) []fs.DirEntry {
+	entries := make([]fs.DirEntry, 0, len(d.dirs)+len(d.files))
+
+	dirs, files := d.Dirs(), d.Files()
+	for len(dirs) > 0 || len(files) > 0 {
+		if len(files) == 0 || (len(dirs) > 0 && dirs[0].name < files[0].name) {
+			entries = append(entries, fs.FileInfoToDirEntry(dirInfo(dirs[0])))
+			dirs = dirs[1:]
+		} else {
+			entries = append(entries, fs.FileInfoToDirEntry(fileInfo(files[0])))
+			files = files[1:]
+		}
+	}
+
+	return entries
+}
+
+type openFile struct {
+	file   *File
+	offset int64
+}
+
+func (f *openFile) Stat() (fs.FileInfo, error) {
+	return fileInfo(f.file), nil
+}
+
+// Read fills p with zeros, since files only have a size.
+func (f *openFile) Read(p []byte) (int, error) {
+	left := f.file.size - f.offset
+	if left <= 0 {
+		return 0, io.EOF
+	}
+
+	n := len(p)
+	if int64(n) > left {
+		n = int(left)
+	}
+
+	for i := 0; i < n; i++ {
+		p[i] = 0
+	}
+
+	f.offset += int64(n)
+	return n, nil
+}
+
+func (f *openFile) Close() error {
+	return nil
+}
+
+type openDir struct {
+	dir     *Dir
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *openDir) Stat() (fs.FileInfo, error) {
+	return dirInfo(d.dir), nil
+}
+
+func (d *openDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.dir.Path(), Err: errors.New("is a directory")}
+}
+
+// ReadDir implements fs.ReadDirFile.
+func (d *openDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	left := d.entries[d.offset:]
+
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return left, nil
+	}
+
+	if len(left) == 0 {
+		return nil, io.EOF
+	}
+
+	if n > len(left) {
+		n = len(left)
+	}
+
+	d.offset += n
+	return left[:n], nil
+}
+
+func (d *openDir) Close() error {
+	return nil
+}
@@ -0,0 +1,215 @@
+package vfs
+
+import (
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+)
+
+// File is a file in the tree. Files only have a size, reading them gives that
+// many zero bytes.
+type File struct {
+	name   string
+	size   int64
+	parent *Dir
+}
+
+func (f *File) Name() string {
+	return f.name
+}
+
+func (f *File) Size() int64 {
+	return f.size
+}
+
+func (f *File) Parent() *Dir {
+	return f.parent
+}
+
+// Path returns the absolute path of the file, or only its name once removed.
+func (f *File) Path() string {
+	if f.parent == nil {
+		return f.name
+	}
+
+	return path.Join(f.parent.Path(), f.name)
+}
+
+type Dir struct {
+	name   string
+	parent *Dir
+	files  map[string]*File
+	dirs   map[string]*Dir
+	// size is the total size of the dir, kept up to date by every change in
+	// the tree below it
+	size int64
+	// removed tells a removed dir apart from a root, as neither has a parent
+	removed bool
+}
+
+// NewDir returns a new dir without parent, to be used as the root of a tree.
+func NewDir(name string) *Dir {
+	return newDir(name, nil)
+}
+
+func newDir(name string, parent *Dir) *Dir {
+	return &Dir{
+		name:   name,
+		parent: parent,
+		files:  make(map[string]*File, 0),
+		dirs:   make(map[string]*Dir, 0),
+	}
+}
+
+func (d *Dir) Name() string {
+	return d.name
+}
+
+// Parent returns the parent dir, or nil for the root.
+func (d *Dir) Parent() *Dir {
+	return d.parent
+}
+
+// Path returns the absolute path of the dir. Once removed, paths below it
+// start at its name, since it is not in any tree anymore.
+func (d *Dir) Path() string {
+	if d.parent == nil {
+		if d.removed {
+			return d.name
+		}
+
+		return "/"
+	}
+
+	return path.Join(d.parent.Path(), d.name)
+}
+
+// Size returns the total size of the files in the dir and all its subdirs.
+func (d *Dir) Size() int64 {
+	return d.size
+}
+
+// grow adds delta to the size of the dir and all its parents.
+func (d *Dir) grow(delta int64) {
+	for cur := d; cur != nil; cur = cur.parent {
+		cur.size += delta
+	}
+}
+
+func (d *Dir) IsEmpty() bool {
+	return len(d.files) == 0 && len(d.dirs) == 0
+}
+
+// Files returns the files directly inside the dir, sorted by name.
+func (d *Dir) Files() []*File {
+	files := make([]*File, 0, len(d.files))
+	for _, f := range d.files {
+		files = append(files, f)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].name < files[j].name
+	})
+
+	return files
+}
+
+// Dirs returns the subdirs directly inside the dir, sorted by name.
+func (d *Dir) Dirs() []*Dir {
+	dirs := make([]*Dir, 0, len(d.dirs))
+	for _, sd := range d.dirs {
+		dirs = append(dirs, sd)
+	}
+
+	sort.Slice(dirs, func(i, j int) bool {
+		return dirs[i].name < dirs[j].name
+	})
+
+	return dirs
+}
+
+func (d *Dir) File(name string) (*File, bool) {
+	f, ok := d.files[name]
+	return f, ok
+}
+
+func (d *Dir) Dir(name string) (*Dir, bool) {
+	sd, ok := d.dirs[name]
+	return sd, ok
+}
+
+// AddDir creates a subdir, or returns the existing one with the same name.
+func (d *Dir) AddDir(name string) (*Dir, error) {
+	if err := d.checkName("mkdir", name); err != nil {
+		return nil, err
+	}
+
+	if sd, ok := d.dirs[name]; ok {
+		return sd, nil
+	}
+
+	if _, ok := d.files[name]; ok {
+		return nil, &fs.PathError{Op: "mkdir", Path: path.Join(d.Path(), name), Err: fs.ErrExist}
+	}
+
+	sd := newDir(name, d)
+	d.dirs[name] = sd
+	return sd, nil
+}
+
+// AddFile creates a file, or sets the size of the existing one with the same
+// name.
+func (d *Dir) AddFile(name string, size int64) (*File, error) {
+	if err := d.checkName("create", name); err != nil {
+		return nil, err
+	}
+
+	if size < 0 {
+		return nil, &fs.PathError{Op: "create", Path: path.Join(d.Path(), name), Err: fs.ErrInvalid}
+	}
+
+	if _, ok := d.dirs[name]; ok {
+		return nil, &fs.PathError{Op: "create", Path: path.Join(d.Path(), name), Err: fs.ErrExist}
+	}
+
+	if f, ok := d.files[name]; ok {
+		d.grow(size - f.size)
+		f.size = size
+		return f, nil
+	}
+
+	f := &File{name: name, size: size, parent: d}
+	d.files[name] = f
+	d.grow(size)
+	return f, nil
+}
+
+// Remove deletes the file or the dir, with all its contents, with that name.
+// The removed one keeps working, but out of the tree.
+func (d *Dir) Remove(name string) error {
+	if f, ok := d.files[name]; ok {
+		delete(d.files, name)
+		f.parent = nil
+		d.grow(-f.size)
+		return nil
+	}
+
+	if sd, ok := d.dirs[name]; ok {
+		delete(d.dirs, name)
+		sd.parent = nil
+		sd.removed = true
+		d.grow(-sd.size)
+		return nil
+	}
+
+	return &fs.PathError{Op: "remove", Path: path.Join(d.Path(), name), Err: fs.ErrNotExist}
+}
+
+func (d *Dir) checkName(op, name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsRune(name, '/') {
+		return &fs.PathError{Op: op, Path: path.Join(d.Path(), name), Err: fs.ErrInvalid}
+	}
+
+	return nil
+}
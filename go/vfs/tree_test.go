@@ -0,0 +1,217 @@
+package vfs
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// recursiveSize adds up the sizes below the dir without the cached ones, the
+// way Size used to work.
+func recursiveSize(d *Dir) int64 {
+	size := int64(0)
+	for _, f := range d.files {
+		size += f.size
+	}
+
+	for _, sd := range d.dirs {
+		size += recursiveSize(sd)
+	}
+
+	return size
+}
+
+// checkSizes fails if any dir below root has a size different to the sum of
+// its contents.
+func checkSizes(t *testing.T, root *Dir) {
+	t.Helper()
+
+	if got, want := root.Size(), recursiveSize(root); got != want {
+		t.Fatalf("size of %s = %d, want %d", root.Path(), got, want)
+	}
+
+	for _, sd := range root.dirs {
+		checkSizes(t, sd)
+	}
+}
+
+func mustCreate(t *testing.T, fsys *FS, p string, size int64) {
+	t.Helper()
+
+	if _, err := fsys.Create(p, size); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustMkdirAll(t *testing.T, fsys *FS, p string) {
+	t.Helper()
+
+	if _, err := fsys.MkdirAll(p); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSizeAfterChanges(t *testing.T) {
+	fsys := New()
+	mustMkdirAll(t, fsys, "/a/b/c")
+	mustMkdirAll(t, fsys, "/d")
+	mustCreate(t, fsys, "/a/x", 10)
+	mustCreate(t, fsys, "/a/b/y", 20)
+	mustCreate(t, fsys, "/a/b/c/z", 40)
+	mustCreate(t, fsys, "/d/w", 80)
+
+	steps := []struct {
+		name     string
+		change   func() error
+		rootSize int64
+	}{
+		{"resize a file", func() error { _, err := fsys.Create("/a/b/c/z", 5); return err }, 115},
+		{"remove a file", func() error { return fsys.Remove("/d/w") }, 35},
+		{"move a dir to its grandparent", func() error { return fsys.Move("/a/b/c", "/c") }, 35},
+		{"move a file into a nested dir", func() error { return fsys.Move("/a/x", "/c/x") }, 35},
+		{"move a dir into a sibling", func() error { return fsys.Move("/c", "/a/b/c") }, 35},
+		{"move a dir to its parent", func() error { return fsys.Move("/a/b/c", "/a/c") }, 35},
+		{"remove a dir with its contents", func() error { return fsys.RemoveAll("/a/b") }, 15},
+		{"add a file", func() error { _, err := fsys.Create("/d/v", 7); return err }, 22},
+	}
+
+	for _, step := range steps {
+		if err := step.change(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+
+		if got := fsys.Root().Size(); got != step.rootSize {
+			t.Fatalf("%s: root size = %d, want %d", step.name, got, step.rootSize)
+		}
+
+		checkSizes(t, fsys.Root())
+	}
+}
+
+func TestPathAfterRemove(t *testing.T) {
+	fsys := New()
+	mustMkdirAll(t, fsys, "/a/b/c")
+	mustCreate(t, fsys, "/a/x", 10)
+	mustCreate(t, fsys, "/a/b/c/y", 20)
+
+	a, err := fsys.LookupDir("/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	x, b := a.files["x"], a.dirs["b"]
+	c := b.dirs["c"]
+	y := c.files["y"]
+
+	if err := fsys.Remove("/a/x"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := fsys.RemoveAll("/a/b"); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{x.Path(), "x"},
+		{b.Path(), "b"},
+		{c.Path(), "b/c"},
+		{y.Path(), "b/c/y"},
+		{a.Path(), "/a"},
+		{fsys.Root().Path(), "/"},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("got path %q, want %q", tt.got, tt.want)
+		}
+	}
+}
+
+func TestSizeAfterRandomChanges(t *testing.T) {
+	r := rand.New(rand.NewSource(2022))
+	fsys := New()
+	dirs := []string{"/"}
+
+	for i := 0; i < 5000; i++ {
+		p := fmt.Sprintf("%s/n%d", dirs[r.Intn(len(dirs))], r.Intn(4))
+
+		// most of the changes fail because of what is already there, which
+		// must not change any size either
+		switch r.Intn(5) {
+		case 0:
+			if _, err := fsys.MkdirAll(p); err == nil {
+				dirs = append(dirs, p)
+			}
+
+		case 1, 2:
+			_, _ = fsys.Create(p, int64(r.Intn(1000)))
+
+		case 3:
+			_ = fsys.RemoveAll(p)
+
+		case 4:
+			_ = fsys.Move(p, fmt.Sprintf("%s/m%d", dirs[r.Intn(len(dirs))], r.Intn(4)))
+		}
+
+		checkSizes(t, fsys.Root())
+	}
+}
+
+// buildTree returns a tree with width subdirs in every dir, depth levels down,
+// and a file in every dir.
+func buildTree(depth, width int) *FS {
+	fsys := New()
+
+	var fill func(d *Dir, level int)
+	fill = func(d *Dir, level int) {
+		_, _ = d.AddFile("file", int64(level+1))
+		if level == depth {
+			return
+		}
+
+		for i := 0; i < width; i++ {
+			sd, _ := d.AddDir(fmt.Sprintf("d%d", i))
+			fill(sd, level+1)
+		}
+	}
+	fill(fsys.Root(), 0)
+
+	return fsys
+}
+
+// BenchmarkSizeEveryDir asks for the size of every dir of a deep and wide
+// tree, as day07 does, with the cached sizes and adding them up every time.
+func BenchmarkSizeEveryDir(b *testing.B) {
+	fsys := buildTree(6, 6)
+
+	dirs := make([]*Dir, 0)
+	queue := []*Dir{fsys.Root()}
+	for len(queue) > 0 {
+		d := queue[0]
+		queue = queue[1:]
+		dirs = append(dirs, d)
+		queue = append(queue, d.Dirs()...)
+	}
+
+	sizeFuncs := []struct {
+		name string
+		size func(*Dir) int64
+	}{
+		{"cached", (*Dir).Size},
+		{"recursive", recursiveSize},
+	}
+
+	for _, sf := range sizeFuncs {
+		b.Run(sf.name, func(b *testing.B) {
+			total := int64(0)
+			for i := 0; i < b.N; i++ {
+				for _, d := range dirs {
+					total += sf.size(d)
+				}
+			}
+		})
+	}
+}